@@ -0,0 +1,8 @@
+//go:build pkcs11
+
+package main
+
+// build with `-tags pkcs11` for use key in HSM, need cgo
+import (
+	_ "superlicense/pkg/key/pkcs11"
+)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto"
 	"crypto/rsa"
 	"fmt"
 	"os"
@@ -42,7 +43,7 @@ var (
 func init() {
 	build.PersistentFlags().StringVarP(&reqEncPemPath, "enckey", "e", "id_rsa.pub.pem", "public key for encrypt")
 
-	parse.PersistentFlags().StringVarP(&reqDecPemPath, "deckey", "d", "id_rsa.pem", "private key for decrypt")
+	parse.PersistentFlags().StringVarP(&reqDecPemPath, "deckey", "d", "id_rsa.pem", "private key for decrypt, or uri of external key, e.g. pkcs11:module=xxx;token=xxx;label=xxx")
 	parse.PersistentFlags().StringVarP(&reqDecPemPassword, "decpassword", "n", "", "password for private key")
 }
 
@@ -92,16 +93,14 @@ func ParseRun(cmd *cobra.Command, args []string) error {
 		fmt.Println("use license req:" + req.ReqV1VersionStr)
 
 		var err error
-		var decPriv *rsa.PrivateKey
+		var decPriv crypto.Decrypter
 		if reqDecPemPath != "" {
 			fmt.Println("use deckey:" + reqDecPemPath)
 
-			decPemData, _ := os.ReadFile(reqDecPemPath)
-			decPrivAny, err := key.ParsePrivFromPem(decPemData, []byte(reqDecPemPassword))
+			decPriv, err = key.OpenDecrypter(reqDecPemPath, []byte(reqDecPemPassword))
 			if err != nil {
 				return errors.Wrap(err, "load private key for decrypt")
 			}
-			decPriv = decPrivAny.(*rsa.PrivateKey)
 		}
 
 		r, err := req.ParseReqV1File(reqFpath, decPriv)
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"superlicense/pkg/license"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	issueProduct string
+	issueEdition string
+	issueAuths   []string
+
+	issue = &cobra.Command{
+		Use:   "issue",
+		Short: "issue license of product",
+		RunE:  IssueRun,
+	}
+)
+
+func init() {
+	addBuildFlags(issue)
+	issue.PersistentFlags().StringVarP(&issueProduct, "product", "", "demo", "product name, see: products list")
+	issue.PersistentFlags().StringVarP(&issueEdition, "edition", "", "", "edition of product, expanded to auths")
+	issue.PersistentFlags().StringArrayVarP(&issueAuths, "auth", "a", nil, "auth of code=value, override open auths of edition")
+}
+
+func IssueRun(cmd *cobra.Command, args []string) error {
+	l, isExist := license.GetLicenseV1(issueProduct)
+	if !isExist {
+		return errors.Errorf("unknown product: %s", issueProduct)
+	}
+
+	auths, err := license.ParseAuthV1Args(l.Checks(), issueAuths)
+	if err != nil {
+		return err
+	}
+
+	auths, err = license.ValidLicenseV1(&license.CreateLicenseV1Req{
+		Name:    issueProduct,
+		Edition: issueEdition,
+		Lang:    licLang,
+		Auths:   auths,
+	})
+	if err != nil {
+		return errors.Wrap(err, "invalid auths")
+	}
+
+	for _, a := range license.VisibleAuthV1s(auths) {
+		fmt.Printf("%s(%s): %s", a.Code, a.Name, a.Content)
+		if a.ExpiredAt != 0 {
+			fmt.Printf(" expired_at=%d", a.ExpiredAt)
+		}
+		fmt.Println()
+	}
+
+	return buildLicense(auths)
+}
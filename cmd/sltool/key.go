@@ -1,8 +1,14 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"superlicense/pkg/key"
+	"superlicense/pkg/lib/atomicfile"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -21,10 +27,10 @@ var (
 )
 
 func init() {
-	keygen.PersistentFlags().StringVarP(&keyType, "type", "t", "rsa", "rsa, ed25519")
+	keygen.PersistentFlags().StringVarP(&keyType, "type", "t", "rsa", "rsa, ed25519, ecdsa, ed25519-mldsa(hybrid post-quantum)")
 	keygen.PersistentFlags().StringVarP(&keyPassword, "password", "P", "", "")
 	keygen.PersistentFlags().StringVarP(&keyFpath, "filename", "f", "", "the filename of the key file")
-	keygen.PersistentFlags().IntVarP(&keyBits, "bits", "b", 4096, "the  number  of  bits in the key to creat, only for rsa")
+	keygen.PersistentFlags().IntVarP(&keyBits, "bits", "b", 4096, "the  number  of  bits in the key to creat, only for rsa(3072, 4096) and ecdsa(256, 384, default is 256)")
 	keygen.PersistentFlags().StringVarP(&keyComment, "comment", "C", "", "")
 }
 
@@ -36,6 +42,24 @@ func KeygenRun(cmd *cobra.Command, args []string) error {
 			Fpath:    keyFpath,
 			Commont:  keyComment,
 		})
+	case key.TypeECDSA:
+		bits := keyBits
+		if !cmd.Flags().Changed("bits") {
+			bits = 256
+		}
+
+		return key.GenerateECDSA(&key.GenerateECDSAReq{
+			Bits:     bits,
+			Password: keyPassword,
+			Fpath:    keyFpath,
+			Commont:  keyComment,
+		})
+	case key.TypeEd25519MLDSA:
+		return key.GenerateHybrid(&key.GenerateHybridReq{
+			Password: keyPassword,
+			Fpath:    keyFpath,
+			Commont:  keyComment,
+		})
 	case key.TypeRSA:
 		return key.GenerateRSA(&key.GenerateRSAReq{
 			Bits:     keyBits,
@@ -47,3 +71,343 @@ func KeygenRun(cmd *cobra.Command, args []string) error {
 		return key.ErrTypeInvalid
 	}
 }
+
+var (
+	keyInfoFpath    string
+	keyInfoPassword string
+
+	keyPubFpath    string
+	keyPubPassword string
+	keyPubOutput   string
+
+	keyPasswdFpath       string
+	keyPasswdPassword    string
+	keyPasswdNewPassword string
+
+	keySplitFpath          string
+	keySplitPassword       string
+	keySplitShares         int
+	keySplitThreshold      int
+	keySplitSharePasswords []string
+
+	keyCombineSharePasswords []string
+	keyCombineOutput         string
+	keyCombinePassword       string
+
+	keyConvertFpath       string
+	keyConvertPassword    string
+	keyConvertTo          string
+	keyConvertOutput      string
+	keyConvertNewPassword string
+	keyConvertComment     string
+
+	keyCmd = &cobra.Command{
+		Use:   "key",
+		Short: "manage key",
+	}
+
+	keyInfo = &cobra.Command{
+		Use:   "info",
+		Short: "show type, size, comment and fingerprint of key",
+		RunE:  KeyInfoRun,
+	}
+
+	keyPub = &cobra.Command{
+		Use:   "pub",
+		Short: "derive public key from private key",
+		RunE:  KeyPubRun,
+	}
+
+	keyPasswd = &cobra.Command{
+		Use:   "passwd",
+		Short: "change password of private key",
+		RunE:  KeyPasswdRun,
+	}
+
+	keySplit = &cobra.Command{
+		Use:   "split",
+		Short: "split private key into shares, any threshold of them can recover it",
+		RunE:  KeySplitRun,
+	}
+
+	keyCombine = &cobra.Command{
+		Use:   "combine [share files]",
+		Short: "recover private key from shares",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  KeyCombineRun,
+	}
+
+	keyConvert = &cobra.Command{
+		Use:   "convert",
+		Short: "convert key between pem, openssh and jwk",
+		RunE:  KeyConvertRun,
+	}
+)
+
+func init() {
+	keyInfo.PersistentFlags().StringVarP(&keyInfoFpath, "filename", "f", "id_ed25519.pem", "the filename of the key file, private or public")
+	keyInfo.PersistentFlags().StringVarP(&keyInfoPassword, "password", "P", "", "only for encrypted private key")
+
+	keyPub.PersistentFlags().StringVarP(&keyPubFpath, "filename", "f", "id_ed25519.pem", "the filename of the private key file")
+	keyPub.PersistentFlags().StringVarP(&keyPubPassword, "password", "P", "", "")
+	keyPub.PersistentFlags().StringVarP(&keyPubOutput, "output", "o", "", "the filename of the public key file, default is xxx.pub.pem")
+
+	keyPasswd.PersistentFlags().StringVarP(&keyPasswdFpath, "filename", "f", "id_ed25519.pem", "the filename of the private key file")
+	keyPasswd.PersistentFlags().StringVarP(&keyPasswdPassword, "password", "P", "", "old password")
+	keyPasswd.PersistentFlags().StringVarP(&keyPasswdNewPassword, "new-password", "N", "", "new password, empty is remove password")
+
+	keySplit.PersistentFlags().StringVarP(&keySplitFpath, "filename", "f", "id_ed25519.pem", "the filename of the private key file")
+	keySplit.PersistentFlags().StringVarP(&keySplitPassword, "password", "P", "", "")
+	keySplit.PersistentFlags().IntVarP(&keySplitShares, "shares", "s", 5, "the number of shares")
+	keySplit.PersistentFlags().IntVarP(&keySplitThreshold, "threshold", "k", 3, "the number of shares to recover the key")
+	keySplit.PersistentFlags().StringSliceVarP(&keySplitSharePasswords, "share-passwords", "S", nil, "password of each share, one password is for all shares")
+
+	keyCombine.PersistentFlags().StringSliceVarP(&keyCombineSharePasswords, "share-passwords", "S", nil, "password of each share, one password is for all shares")
+	keyCombine.PersistentFlags().StringVarP(&keyCombineOutput, "output", "o", "", "save the recovered private key, default is only check the shares")
+	keyCombine.PersistentFlags().StringVarP(&keyCombinePassword, "password", "P", "", "password for the recovered private key")
+
+	keyConvert.PersistentFlags().StringVarP(&keyConvertFpath, "filename", "f", "id_ed25519.pem", "the filename of the key file, private or public, format is auto detected")
+	keyConvert.PersistentFlags().StringVarP(&keyConvertPassword, "password", "P", "", "only for encrypted private key")
+	keyConvert.PersistentFlags().StringVarP(&keyConvertTo, "to", "t", key.FormatOpenSSH, "pem, openssh, jwk")
+	keyConvert.PersistentFlags().StringVarP(&keyConvertOutput, "output", "o", "", "the filename of the output, default is stdout")
+	keyConvert.PersistentFlags().StringVarP(&keyConvertNewPassword, "new-password", "N", "", "password of the output private key, unsupported by jwk")
+	keyConvert.PersistentFlags().StringVarP(&keyConvertComment, "comment", "C", "", "only for pem and openssh")
+
+	keyCmd.AddCommand(keyInfo)
+	keyCmd.AddCommand(keyPub)
+	keyCmd.AddCommand(keyPasswd)
+	keyCmd.AddCommand(keySplit)
+	keyCmd.AddCommand(keyCombine)
+	keyCmd.AddCommand(keyConvert)
+}
+
+func KeyInfoRun(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(keyInfoFpath)
+	if err != nil {
+		return errors.Wrap(err, "load key")
+	}
+
+	info, err := key.ParseInfoFromPem(data, []byte(keyInfoPassword))
+	if err != nil {
+		return errors.Wrap(err, "parse key")
+	}
+
+	fmt.Printf("type: %s\n", info.Type)
+	if info.Bits > 0 {
+		fmt.Printf("bits: %d\n", info.Bits)
+	}
+	fmt.Printf("comment: %s\n", info.Comment)
+	fmt.Printf("private: %t\n", info.Private)
+	fmt.Printf("encrypted: %t\n", info.Encrypted)
+	fmt.Printf("fingerprint: %s\n", info.Fingerprint)
+
+	return nil
+}
+
+func KeyPubRun(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(keyPubFpath)
+	if err != nil {
+		return errors.Wrap(err, "load private key")
+	}
+
+	pubBuf, err := key.ExportPubFromPem(data, []byte(keyPubPassword))
+	if err != nil {
+		return errors.Wrap(err, "export public key")
+	}
+
+	output := keyPubOutput
+	if output == "" {
+		output = strings.TrimSuffix(keyPubFpath, ".pem") + ".pub.pem"
+	}
+
+	if err = atomicfile.WriteFile(output, pubBuf, 0644); err != nil {
+		return errors.Wrap(err, "write public key file")
+	}
+
+	fmt.Printf("export public key ok: %s\n", output)
+
+	return nil
+}
+
+func KeyPasswdRun(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(keyPasswdFpath)
+	if err != nil {
+		return errors.Wrap(err, "load private key")
+	}
+
+	privBuf, err := key.ChangePemPassword(data, []byte(keyPasswdPassword), []byte(keyPasswdNewPassword))
+	if err != nil {
+		return errors.Wrap(err, "change password")
+	}
+
+	if err = atomicfile.WriteFile(keyPasswdFpath, privBuf, 0600); err != nil {
+		return errors.Wrap(err, "write private key file")
+	}
+
+	fmt.Printf("change password ok: %s\n", keyPasswdFpath)
+
+	return nil
+}
+
+func KeySplitRun(cmd *cobra.Command, args []string) error {
+	if len(keySplitSharePasswords) > 1 && len(keySplitSharePasswords) != keySplitShares {
+		return errors.New("the number of share passwords must be 1 or equal to shares")
+	}
+
+	data, err := os.ReadFile(keySplitFpath)
+	if err != nil {
+		return errors.Wrap(err, "load private key")
+	}
+
+	priv, err := key.ParsePrivFromPem(data, []byte(keySplitPassword))
+	if err != nil {
+		return errors.Wrap(err, "parse private key")
+	}
+
+	shares, err := key.SplitPriv(priv, keySplitShares, keySplitThreshold)
+	if err != nil {
+		return err
+	}
+
+	prefix := strings.TrimSuffix(keySplitFpath, ".pem")
+	for i, s := range shares {
+		var password, salt []byte
+		if p := sharePassword(keySplitSharePasswords, i); p != "" {
+			if password, salt, err = key.GenerateKDFKey([]byte(p), nil); err != nil {
+				return err
+			}
+		}
+
+		shareBuf, err := key.EncodeShareToPem(s, password, salt)
+		if err != nil {
+			return errors.Wrapf(err, "generate share %d", s.Index)
+		}
+
+		fpath := fmt.Sprintf("%s.share%d.pem", prefix, s.Index)
+		if err = atomicfile.WriteFile(fpath, shareBuf, 0600); err != nil {
+			return errors.Wrap(err, "write share file")
+		}
+
+		fmt.Printf("write share: %s\n", fpath)
+	}
+
+	fmt.Printf("split key ok: %d shares, threshold is %d\n", keySplitShares, keySplitThreshold)
+
+	return nil
+}
+
+func KeyCombineRun(cmd *cobra.Command, args []string) error {
+	priv, err := combineShares(args, keyCombineSharePasswords)
+	if err != nil {
+		return err
+	}
+
+	pub, err := key.PubFromPriv(priv)
+	if err != nil {
+		return err
+	}
+
+	fp, err := key.Fingerprint(pub)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("combine key ok, fingerprint: %s\n", fp)
+
+	if keyCombineOutput == "" {
+		return nil
+	}
+
+	var password, salt []byte
+	if keyCombinePassword != "" {
+		if password, salt, err = key.GenerateKDFKey([]byte(keyCombinePassword), nil); err != nil {
+			return err
+		}
+	}
+
+	privBuf, err := key.EncodePrivToPem(priv, password, salt, "")
+	if err != nil {
+		return errors.Wrap(err, "generate private pem")
+	}
+
+	if err = atomicfile.WriteFile(keyCombineOutput, privBuf, 0600); err != nil {
+		return errors.Wrap(err, "write private key file")
+	}
+
+	fmt.Printf("write private key: %s\n", keyCombineOutput)
+
+	return nil
+}
+
+// combineShares recover private key in memory
+func combineShares(fpaths []string, passwords []string) (any, error) {
+	if len(passwords) > 1 && len(passwords) != len(fpaths) {
+		return nil, errors.New("the number of share passwords must be 1 or equal to shares")
+	}
+
+	shares := make([]*key.Share, 0, len(fpaths))
+	for i, fpath := range fpaths {
+		data, err := os.ReadFile(fpath)
+		if err != nil {
+			return nil, errors.Wrap(err, "load share")
+		}
+
+		s, err := key.ParseShareFromPem(data, []byte(sharePassword(passwords, i)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse share: %s", fpath)
+		}
+
+		shares = append(shares, s)
+	}
+
+	return key.CombinePriv(shares)
+}
+
+func sharePassword(passwords []string, i int) string {
+	switch len(passwords) {
+	case 0:
+		return ""
+	case 1:
+		return passwords[0]
+	default:
+		return passwords[i]
+	}
+}
+
+func KeyConvertRun(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(keyConvertFpath)
+	if err != nil {
+		return errors.Wrap(err, "load key")
+	}
+
+	k, err := key.ParseKey(data, []byte(keyConvertPassword))
+	if err != nil {
+		return errors.Wrap(err, "parse key")
+	}
+
+	var buf []byte
+	perm := os.FileMode(0644)
+	if _, err = key.PubFromPriv(k); err == nil {
+		perm = 0600
+		buf, err = key.EncodePriv(k, keyConvertTo, []byte(keyConvertNewPassword), keyConvertComment)
+	} else {
+		buf, err = key.EncodePub(k, keyConvertTo, keyConvertComment)
+	}
+	if err != nil {
+		return errors.Wrap(err, "convert key")
+	}
+
+	if keyConvertOutput == "" {
+		fmt.Print(string(buf))
+
+		return nil
+	}
+
+	if err = atomicfile.WriteFile(keyConvertOutput, buf, perm); err != nil {
+		return errors.Wrap(err, "write key file")
+	}
+
+	fmt.Printf("convert key ok: %s\n", keyConvertOutput)
+
+	return nil
+}
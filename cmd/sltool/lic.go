@@ -1,10 +1,11 @@
 package main
 
 import (
-	"crypto/ed25519"
+	"crypto"
 	"crypto/rsa"
 	"fmt"
 	"os"
+	"strings"
 
 	"superlicense/pkg/key"
 	"superlicense/pkg/license"
@@ -17,14 +18,21 @@ import (
 var (
 	licFpath   string
 	licVersion string
+	licLang    string
 
 	licSignPemPath     string
 	licEncPemPath      string
 	licSignPemPassword string
+	licSignShares      []string
+	licSharePasswords  []string
 	licEncPemPassword  string
 
 	licVerifyPemPath string
 	licDecPemPath    string
+	licProduct       string
+	licTicketFpath   string
+
+	licInteractive bool
 )
 
 var (
@@ -43,45 +51,92 @@ var (
 )
 
 func init() {
-	build.PersistentFlags().StringVarP(&licSignPemPath, "signkey", "p", "id_ed25519.pem", "private key for sign")
-	build.PersistentFlags().StringVarP(&licEncPemPath, "enckey", "e", "id_rsa.pem", "private key for encrypt")
-	build.PersistentFlags().StringVarP(&licSignPemPassword, "signpassword", "m", "", "password for sign private key")
-	build.PersistentFlags().StringVarP(&licEncPemPassword, "encpassword", "n", "", "password for encrypt private key")
+	addBuildFlags(build)
+	build.PersistentFlags().BoolVarP(&licInteractive, "interactive", "i", false, "build by wizard: choose product, input auths, preview and sign")
 
-	parse.PersistentFlags().StringVarP(&licVerifyPemPath, "verifykey", "", "id_ed25519.pub.pem", "public key for verify sign")
+	parse.PersistentFlags().StringVarP(&licVerifyPemPath, "verifykey", "", "id_ed25519.pub.pem", "public key for verify sign, pem, openssh or jwk")
 	parse.PersistentFlags().StringVarP(&licDecPemPath, "deckey", "d", "id_rsa.pub.pem", "public key for decrypt")
+	parse.PersistentFlags().StringVarP(&licProduct, "product", "", "", "product of license, for --lang")
+	parse.PersistentFlags().StringVarP(&licTicketFpath, "ticket", "", "", "time ticket of license, the lower bound of now for expiry")
+}
+
+// addSignFlags flags of sign key, shared by build, issue and ticket
+func addSignFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&licSignPemPath, "signkey", "p", "id_ed25519.pem", "private key for sign, or uri of external key, e.g. pkcs11:module=xxx;token=xxx;label=xxx")
+	cmd.PersistentFlags().StringSliceVarP(&licSignShares, "signshares", "", nil, "shares of private key for sign, instead of signkey")
+	cmd.PersistentFlags().StringSliceVarP(&licSharePasswords, "sharepasswords", "", nil, "password of each share, one password is for all shares")
+	cmd.PersistentFlags().StringVarP(&licSignPemPassword, "signpassword", "m", "", "password for sign private key")
+}
+
+// addBuildFlags flags of keys for build license, shared by build and issue
+func addBuildFlags(cmd *cobra.Command) {
+	addSignFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&licEncPemPath, "enckey", "e", "id_rsa.pem", "private key for encrypt, or uri of external key")
+	cmd.PersistentFlags().StringVarP(&licEncPemPassword, "encpassword", "n", "", "password for encrypt private key")
 }
 
 func BuildRun(cmd *cobra.Command, args []string) error {
+	if licInteractive {
+		auths, err := newWizard(os.Stdin, os.Stdout).Run()
+		if err != nil {
+			return err
+		}
+
+		return buildLicense(auths)
+	}
+
+	auths := []*license.AuthV1{
+		{
+			Code:    "id",
+			Name:    "ID",
+			Content: "test",
+		},
+	}
+
+	return buildLicense(auths)
+}
+
+// loadSignKey by signkey or signshares
+func loadSignKey() (crypto.Signer, error) {
+	if len(licSignShares) != 0 {
+		fmt.Printf("use signshares: %s\n", strings.Join(licSignShares, ","))
+
+		signPrivAny, err := combineShares(licSignShares, licSharePasswords)
+		if err != nil {
+			return nil, errors.Wrap(err, "load private key for sign")
+		}
+
+		return signPrivAny.(crypto.Signer), nil
+	}
+
+	fmt.Println("use signkey:" + licSignPemPath)
+
+	signPriv, err := key.OpenSigner(licSignPemPath, []byte(licSignPemPassword))
+	if err != nil {
+		return nil, errors.Wrap(err, "load private key for sign")
+	}
+
+	return signPriv, nil
+}
+
+// buildLicense sign and encrypt auths by keys of build flags
+func buildLicense(auths []*license.AuthV1) error {
 	switch licVersion {
 	case license.LicenseV1VersionStr:
 		fmt.Println("use license:" + license.LicenseV1VersionStr)
-		fmt.Println("use signkey:" + licSignPemPath)
-
-		signPemData, _ := os.ReadFile(licSignPemPath)
-		signPriv, err := key.ParsePrivFromPem(signPemData, []byte(licSignPemPassword))
+		signPriv, err := loadSignKey()
 		if err != nil {
-			return errors.Wrap(err, "load private key for sign")
+			return err
 		}
 
-		var encPriv *rsa.PrivateKey
+		var encPriv crypto.Signer
 		if licEncPemPath != "" {
 			fmt.Println("use enckey:" + licEncPemPath)
 
-			encPemData, _ := os.ReadFile(licEncPemPath)
-			encPrivAny, err := key.ParsePrivFromPem(encPemData, []byte(licEncPemPassword))
+			encPriv, err = key.OpenSigner(licEncPemPath, []byte(licEncPemPassword))
 			if err != nil {
 				return errors.Wrap(err, "load private key for encrypt")
 			}
-			encPriv = encPrivAny.(*rsa.PrivateKey)
-		}
-
-		auths := []*license.AuthV1{
-			{
-				Code:    "id",
-				Name:    "ID",
-				Content: "test",
-			},
 		}
 
 		flag := license.LicenseV1FlagRaw
@@ -89,7 +144,7 @@ func BuildRun(cmd *cobra.Command, args []string) error {
 			flag |= license.LicenseV1FlagCiphertext
 		}
 
-		err = license.BuildLicenseV1File(licFpath, auths, signPriv.(ed25519.PrivateKey), encPriv, flag)
+		err = license.BuildLicenseV1File(licFpath, auths, signPriv, encPriv, flag)
 		if err != nil {
 			return errors.Wrap(err, "build license")
 		}
@@ -109,7 +164,7 @@ func ParseRun(cmd *cobra.Command, args []string) error {
 		fmt.Println("use verifykey:" + licVerifyPemPath)
 
 		verifyPemData, _ := os.ReadFile(licVerifyPemPath)
-		verifyPub, err := key.ParsePubFromPem(verifyPemData)
+		verifyPub, err := key.ParsePub(verifyPemData)
 		if err != nil {
 			return errors.Wrap(err, "load public key for verify sign")
 		}
@@ -119,19 +174,40 @@ func ParseRun(cmd *cobra.Command, args []string) error {
 			fmt.Println("use deckey:" + licDecPemPath)
 
 			decPemData, _ := os.ReadFile(licDecPemPath)
-			decPubAny, err := key.ParsePubFromPem(decPemData)
+			decPubAny, err := key.ParsePub(decPemData)
 			if err != nil {
 				return errors.Wrap(err, "load public key for decrypt")
 			}
 			decPub = decPubAny.(*rsa.PublicKey)
 		}
 
-		l, err := license.ParseLicenseV1File(licFpath, verifyPub.(ed25519.PublicKey), decPub)
+		l, err := license.ParseLicenseV1File(licFpath, verifyPub, decPub)
 		if err != nil {
 			return errors.Wrap(err, "parse license")
 		}
 
-		spew.Dump(l.Auths)
+		auths := l.Auths
+		if licLang != "" {
+			auths = license.LocalizeLicenseV1(licProduct, auths, licLang)
+		}
+
+		spew.Dump(auths)
+
+		env := &license.AuthV1Env{}
+		if licTicketFpath != "" {
+			fmt.Println("use ticket:" + licTicketFpath)
+
+			t, err := license.ParseTicketV1File(licTicketFpath, verifyPub)
+			if err != nil {
+				return errors.Wrap(err, "parse ticket")
+			}
+			if err = env.UseTicket(l, t); err != nil {
+				return errors.Wrap(err, "use ticket")
+			}
+		}
+
+		fmt.Printf("id: %s\n", l.ID())
+		fmt.Printf("expiry: %s\n", l.Expiry(env))
 
 		return nil
 	default:
@@ -5,17 +5,26 @@ import (
 )
 
 var (
-	rootCmd = &cobra.Command{Use: "sltool"}
+	rootCmd = &cobra.Command{
+		Use:               "sltool",
+		PersistentPreRunE: loadProducts,
+	}
 )
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&licVersion, "version", "v", "v1", "license version")
 	rootCmd.PersistentFlags().StringVarP(&licFpath, "path", "l", "license.dat", "license path")
+	rootCmd.PersistentFlags().StringVarP(&productsFpath, "products", "", "", "yaml/json file of declarative products")
+	rootCmd.PersistentFlags().StringVarP(&licLang, "lang", "", "", "lang of auth name/remark, e.g. zh, en, default is as defined")
 }
 
 func main() {
 	rootCmd.AddCommand(keygen)
+	rootCmd.AddCommand(keyCmd)
 	rootCmd.AddCommand(build)
 	rootCmd.AddCommand(parse)
+	rootCmd.AddCommand(issue)
+	rootCmd.AddCommand(ticket)
+	rootCmd.AddCommand(productsCmd)
 	rootCmd.Execute()
 }
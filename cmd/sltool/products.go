@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"superlicense/pkg/license"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	productsFpath string
+
+	productsCmd = &cobra.Command{
+		Use:   "products",
+		Short: "manage products",
+	}
+
+	productsList = &cobra.Command{
+		Use:   "list",
+		Short: "list registered products and auths",
+		RunE:  ProductsListRun,
+	}
+
+	productsSchema = &cobra.Command{
+		Use:   "schema <product>",
+		Short: "print JSON Schema of auths of product, for form-driven UIs",
+		Args:  cobra.ExactArgs(1),
+		RunE:  ProductsSchemaRun,
+	}
+)
+
+func init() {
+	productsCmd.AddCommand(productsList)
+	productsCmd.AddCommand(productsSchema)
+}
+
+// loadProducts register products of --products, declarative products without code release
+func loadProducts(cmd *cobra.Command, args []string) error {
+	if productsFpath == "" {
+		return nil
+	}
+
+	if err := license.RegisterProductV1File(productsFpath); err != nil {
+		return errors.Wrap(err, "load products")
+	}
+
+	return nil
+}
+
+func ProductsListRun(cmd *cobra.Command, args []string) error {
+	for _, l := range license.ListLicenseV1() {
+		fmt.Println(l.Name())
+
+		for _, c := range l.Checks() {
+			flags := ""
+			if c.Requred {
+				flags += " required"
+			}
+			if c.RequredContent {
+				flags += " content"
+			}
+			if c.RequredExpired {
+				flags += " expired"
+			}
+			if c.Hidden {
+				flags += " hidden"
+			}
+			if c.Default != nil {
+				flags += " default=" + *c.Default
+			}
+			if c.Validator != nil {
+				flags += " validator=" + c.Validator.Type
+			}
+
+			text := c.Text(licLang)
+
+			fmt.Printf("  %s(%s):%s", c.Code, text.Name, flags)
+			if c.Example != "" {
+				fmt.Printf(" example=%s", c.Example)
+			}
+			if text.Tip != "" {
+				fmt.Printf(" tip=%s", text.Tip)
+			}
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
+
+func ProductsSchemaRun(cmd *cobra.Command, args []string) error {
+	data, err := license.MarshalLicenseV1Schema(args[0], licLang)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
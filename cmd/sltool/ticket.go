@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"superlicense/pkg/license"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ticketFpath    string
+	ticketID       string
+	ticketNotAfter string
+
+	ticket = &cobra.Command{
+		Use:   "ticket",
+		Short: "issue signed time ticket of license, for machine without trusted clock",
+		RunE:  TicketRun,
+	}
+)
+
+func init() {
+	addSignFlags(ticket)
+	ticket.PersistentFlags().StringVarP(&ticketFpath, "output", "o", "ticket.dat", "the filename of ticket")
+	ticket.PersistentFlags().StringVarP(&ticketID, "id", "", "", "license id, default is id of license by --path")
+	ticket.PersistentFlags().StringVarP(&ticketNotAfter, "not-after", "", "+7d", "ticket is expired after it, e.g. +7d, 2027-01-01")
+}
+
+func TicketRun(cmd *cobra.Command, args []string) error {
+	if licVersion != license.LicenseV1VersionStr {
+		return license.ErrUnsupportVersion
+	}
+
+	id := ticketID
+	if id == "" {
+		var err error
+		if id, err = license.LicenseV1IDOfFile(licFpath); err != nil {
+			return errors.Wrap(err, "get license id")
+		}
+	}
+
+	notAfter, err := license.ParseAuthV1Date(ticketNotAfter)
+	if err != nil {
+		return errors.Wrap(err, "invalid not-after")
+	}
+
+	now := time.Now()
+	if notAfter <= now.Unix() {
+		return errors.New("not-after is in the past")
+	}
+
+	signPriv, err := loadSignKey()
+	if err != nil {
+		return err
+	}
+
+	t := license.NewTicketV1(id, now, time.Unix(notAfter, 0).Sub(now))
+	if err = license.BuildTicketV1File(ticketFpath, t, signPriv); err != nil {
+		return errors.Wrap(err, "build ticket")
+	}
+
+	fmt.Printf("build ticket ok: %s, license: %s, issued_at: %s, not_after: %s\n", ticketFpath, id,
+		time.Unix(t.IssuedAt, 0).Format(time.RFC3339), time.Unix(t.NotAfter, 0).Format(time.RFC3339))
+
+	return nil
+}
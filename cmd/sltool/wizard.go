@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"superlicense/pkg/license"
+
+	"github.com/pkg/errors"
+)
+
+// wizard interactive build: choose product and edition, input auths with live check, preview before sign
+type wizard struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+func newWizard(in io.Reader, out io.Writer) *wizard {
+	return &wizard{
+		in:  bufio.NewScanner(in),
+		out: out,
+	}
+}
+
+// ask empty input returns def
+func (w *wizard) ask(prompt, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", prompt, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", prompt)
+	}
+
+	if !w.in.Scan() {
+		if err := w.in.Err(); err != nil {
+			return "", err
+		}
+
+		return "", io.EOF
+	}
+
+	v := strings.TrimSpace(w.in.Text())
+	if v == "" {
+		return def, nil
+	}
+
+	return v, nil
+}
+
+func (w *wizard) chooseProduct() (license.LicenserV1, error) {
+	ls := license.ListLicenseV1()
+	if len(ls) == 0 {
+		return nil, errors.New("no registered product")
+	}
+
+	fmt.Fprintln(w.out, "products:")
+	for i, l := range ls {
+		fmt.Fprintf(w.out, "  %d) %s\n", i+1, l.Name())
+	}
+
+	for {
+		v, err := w.ask("product", ls[0].Name())
+		if err != nil {
+			return nil, err
+		}
+
+		if i, err := strconv.Atoi(v); err == nil && i >= 1 && i <= len(ls) {
+			return ls[i-1], nil
+		}
+		if l, isExist := license.GetLicenseV1(v); isExist {
+			return l, nil
+		}
+
+		fmt.Fprintf(w.out, "unknown product: %s\n", v)
+	}
+}
+
+// chooseEdition empty is without edition
+func (w *wizard) chooseEdition(l license.LicenserV1) (string, map[string]string, map[string]string, error) {
+	le, ok := l.(license.LicenserV1Editions)
+	if !ok || len(le.Editions()) == 0 {
+		return "", nil, nil, nil
+	}
+
+	names := make([]string, 0)
+	for _, e := range le.Editions() {
+		names = append(names, e.Name)
+	}
+
+	for {
+		v, err := w.ask("edition("+strings.Join(names, "|")+", empty is none)", "")
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if v == "" {
+			return "", nil, nil, nil
+		}
+
+		fixed, open, err := license.EditionV1Presets(l, v)
+		if err != nil {
+			fmt.Fprintln(w.out, err)
+
+			continue
+		}
+
+		return v, fixed, open, nil
+	}
+}
+
+// askAuth check input by Check live, nil is omitted
+func (w *wizard) askAuth(c *license.AuthV1Check, def string) (*license.AuthV1, error) {
+	text := c.Text(licLang)
+
+	fmt.Fprintf(w.out, "\n%s(%s)", c.Code, text.Name)
+	if c.Requred {
+		fmt.Fprint(w.out, " *required")
+	}
+	fmt.Fprintln(w.out)
+	if text.Remark != "" {
+		fmt.Fprintf(w.out, "  remark: %s\n", text.Remark)
+	}
+	if c.Example != "" {
+		fmt.Fprintf(w.out, "  example: %s\n", c.Example)
+	}
+	if text.Tip != "" {
+		fmt.Fprintf(w.out, "  tip: %s\n", text.Tip)
+	}
+
+	if def == "" && c.Default != nil {
+		def = *c.Default
+	}
+
+	for {
+		prompt := "value"
+		switch {
+		case !c.RequredContent && !c.RequredExpired:
+			prompt = "enable(y/n)"
+		case c.RequredExpired && !c.RequredContent:
+			prompt = "expire at(2006-01-02, +365d, end-of-quarter...)"
+		}
+		if !c.Requred {
+			prompt += ", empty to skip"
+		}
+
+		v, err := w.ask(prompt, def)
+		if err != nil {
+			return nil, err
+		}
+		if v == "" {
+			if !c.Requred {
+				return nil, nil
+			}
+
+			fmt.Fprintln(w.out, "  required")
+
+			continue
+		}
+
+		if !c.RequredContent && !c.RequredExpired {
+			if v == "y" {
+				return &license.AuthV1{Code: c.Code}, nil
+			}
+
+			return nil, nil
+		}
+
+		value := v
+		if c.RequredContent && c.RequredExpired {
+			exp, err := w.ask("expire at(2006-01-02, +365d, end-of-quarter...)", "")
+			if err != nil {
+				return nil, err
+			}
+
+			a := &license.AuthV1{Code: c.Code, Content: v}
+			if a.ExpiredAt, err = license.ParseAuthV1Date(exp); err != nil {
+				fmt.Fprintf(w.out, "  %s\n", err)
+
+				continue
+			}
+
+			if _, err = license.GenerateAuthV1s([]*license.AuthV1Check{c}, []*license.AuthV1{a}); err != nil {
+				fmt.Fprintf(w.out, "  %s\n", err)
+
+				continue
+			}
+
+			return a, nil
+		}
+
+		as, err := license.ParseAuthV1Args([]*license.AuthV1Check{c}, []string{c.Code + "=" + value})
+		if err == nil {
+			_, err = license.GenerateAuthV1s([]*license.AuthV1Check{c}, as)
+		}
+		if err != nil {
+			fmt.Fprintf(w.out, "  %s\n", err)
+
+			continue
+		}
+
+		return as[0], nil
+	}
+}
+
+func (w *wizard) preview(auths []*license.AuthV1) {
+	fmt.Fprintln(w.out, "\npreview:")
+	for _, a := range auths {
+		fmt.Fprintf(w.out, "  %s(%s): %s", a.Code, a.Name, a.Content)
+		if a.ExpiredAt != 0 {
+			fmt.Fprintf(w.out, " expired_at=%s", time.Unix(a.ExpiredAt, 0).Format(time.RFC3339))
+		}
+		if a.Hidden {
+			fmt.Fprint(w.out, " (hidden)")
+		}
+		fmt.Fprintln(w.out)
+	}
+
+	data, _ := json.MarshalIndent(auths, "  ", "  ")
+	fmt.Fprintf(w.out, "  %s\n", data)
+}
+
+// Run return the valid auths, confirmed by user
+func (w *wizard) Run() ([]*license.AuthV1, error) {
+	l, err := w.chooseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	edition, fixed, open, err := w.chooseEdition(l)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make(map[string]*license.AuthV1)
+	askable := make(map[string]bool) // not fixed by edition
+	for _, c := range l.Checks() {
+		if v, ok := fixed[c.Code]; ok {
+			fmt.Fprintf(w.out, "\n%s(%s): %s, fixed by edition\n", c.Code, c.Text(licLang).Name, v)
+
+			continue
+		}
+
+		askable[c.Code] = true
+	}
+
+	codes := askable // to ask, all at first, then the failing ones
+
+	var auths []*license.AuthV1
+	for {
+		for _, c := range l.Checks() {
+			if !codes[c.Code] {
+				continue
+			}
+
+			def := open[c.Code]
+			if a := inputs[c.Code]; a != nil && c.RequredContent {
+				def = a.Content
+			}
+
+			a, err := w.askAuth(c, def)
+			if err != nil {
+				return nil, err
+			}
+			inputs[c.Code] = a
+		}
+
+		auths = make([]*license.AuthV1, 0, len(inputs))
+		for _, c := range l.Checks() {
+			if a := inputs[c.Code]; a != nil {
+				auths = append(auths, a)
+			}
+		}
+
+		auths, err = license.ValidLicenseV1(&license.CreateLicenseV1Req{
+			Name:    l.Name(),
+			Edition: edition,
+			Lang:    licLang,
+			Auths:   auths,
+		})
+		if err == nil {
+			break
+		}
+
+		// keep the session, only ask the failing auths again
+		es, ok := license.AsAuthV1Errors(err)
+		if !ok {
+			return nil, errors.Wrap(err, "invalid auths")
+		}
+
+		fmt.Fprintln(w.out, "\ninvalid auths:")
+		codes = make(map[string]bool)
+		for _, e := range es {
+			fmt.Fprintf(w.out, "  %s\n", e)
+
+			if askable[e.Code] {
+				codes[e.Code] = true
+			}
+		}
+		if len(codes) == 0 { // e.g. rule without auth, or fixed by edition
+			return nil, errors.Wrap(err, "invalid auths")
+		}
+	}
+
+	w.preview(auths)
+
+	v, err := w.ask("sign license? (y/n)", "n")
+	if err != nil {
+		return nil, err
+	}
+	if v != "y" {
+		return nil, errors.New("canceled")
+	}
+
+	return auths, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"superlicense/pkg/license"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const wizardTestYaml = `
+name: wizard_test
+auths:
+  - code: expired_at
+    required: true
+    required_expired: true
+    validator: {type: date, after: now}
+  - code: is_try
+    required_content: true
+    validator: {type: bool}
+  - code: max_users
+    required_content: true
+    validator: {type: int, min: 1}
+  - code: features
+    required_content: true
+    validator: {type: list, values: [report, export]}
+rules:
+  - name: try_expiry
+    when: [{code: is_try, op: eq, value: t}]
+    then: [{code: expired_at, op: within_days, value: "30"}]
+editions:
+  - name: pro
+    fixed: {features: "report,export"}
+    open: {max_users: "100"}
+`
+
+func TestWizard(t *testing.T) {
+	ds, err := license.ParseProductV1s([]byte(wizardTestYaml))
+	assert.Nil(t, err)
+	l, err := license.NewProductV1(ds[0])
+	assert.Nil(t, err)
+	assert.Nil(t, license.TryRegisterLicenseV1(l))
+
+	in := strings.Join([]string{
+		"wizard_test", // product
+		"pro",         // edition
+		"someday",     // expired_at, invalid
+		"+100d",       // expired_at, retry
+		"t",           // is_try
+		"",            // max_users, open of edition
+		"+10d",        // expired_at, violates try_expiry, asked again
+		"y",           // sign
+	}, "\n") + "\n"
+	out := &bytes.Buffer{}
+
+	auths, err := newWizard(strings.NewReader(in), out).Run()
+	assert.Nil(t, err)
+
+	lic := &license.LicenseV1{Auths: auths}
+	n, _ := lic.Int("max_users")
+	assert.Equal(t, int64(100), n)
+	fs, _ := lic.List("features")
+	assert.Equal(t, []string{"report", "export"}, fs)
+	assert.NotNil(t, lic.Auth("expired_at"))
+
+	assert.Contains(t, out.String(), "features(): report,export, fixed by edition")
+	assert.Contains(t, out.String(), "violate Rule(try_expiry)")
+	assert.Equal(t, 1, strings.Count(out.String(), "\nis_try("), "only failing auth is asked again")
+
+	// canceled at the final confirm
+	in = strings.Replace(in, "\ny\n", "\nn\n", 1)
+	_, err = newWizard(strings.NewReader(in), &bytes.Buffer{}).Run()
+	assert.NotNil(t, err)
+}
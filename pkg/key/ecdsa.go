@@ -0,0 +1,79 @@
+package key
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+type GenerateECDSAReq struct {
+	Bits      int
+	Password  string
+	_Password []byte
+	_Salt     []byte
+	Fpath     string
+	Commont   string
+}
+
+func (r *GenerateECDSAReq) Valid() error {
+	if !(r.Bits == 256 || r.Bits == 384) {
+		return ErrBitsInvalidECDSA
+	}
+
+	if r.Fpath == "" {
+		r.Fpath = "id_ecdsa"
+	}
+	if err := checkKeyNotExist(r.Fpath); err != nil {
+		return err
+	}
+
+	if r.Password != "" {
+		var err error
+		if r._Password, r._Salt, err = GenerateKDFKey([]byte(r.Password), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GenerateECDSA(r *GenerateECDSAReq) error {
+	var err error
+	if err = r.Valid(); err != nil {
+		return err
+	}
+
+	curve := elliptic.P256()
+	if r.Bits == 384 {
+		curve = elliptic.P384()
+	}
+
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return errors.Wrap(err, "generating ECDSA private key")
+	}
+	pub := &priv.PublicKey
+
+	// Encode the private key to the PEM format
+	privBuf, err := EncodePrivToPem(priv, r._Password, r._Salt, r.Commont)
+	if err != nil {
+		return errors.Wrap(err, "generate private pem")
+	}
+
+	// Encode the public key to the PEM format
+	pubBuf, err := EncodePubToPem(pub)
+	if err != nil {
+		return errors.Wrap(err, "generate public pem")
+	}
+
+	if err = writeKeyPair(r.Fpath, privBuf, pubBuf); err != nil {
+		return err
+	}
+
+	fmt.Println("ECDSA key pair generated successfully!")
+
+	return nil
+}
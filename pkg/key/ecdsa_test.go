@@ -0,0 +1,45 @@
+package key
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ECDSATest struct {
+	In  *GenerateECDSAReq
+	Out error
+}
+
+func TestGenerateECDSA(t *testing.T) {
+	cases := []ECDSATest{
+		{
+			In: &GenerateECDSAReq{
+				Bits: 256,
+			},
+			Out: nil,
+		},
+		{
+			In: &GenerateECDSAReq{
+				Bits:     384,
+				Password: "123456",
+			},
+			Out: nil,
+		},
+		{
+			In: &GenerateECDSAReq{
+				Bits: 521,
+			},
+			Out: ErrBitsInvalidECDSA,
+		},
+	}
+
+	for _, c := range cases {
+		err := GenerateECDSA(c.In)
+		assert.True(t, c.Out == err)
+
+		os.Remove("id_ecdsa.pem")
+		os.Remove("id_ecdsa.pub.pem")
+	}
+}
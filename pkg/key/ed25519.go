@@ -4,7 +4,6 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"fmt"
-	"os"
 
 	"github.com/pkg/errors"
 )
@@ -18,13 +17,12 @@ type GenerateEd25519Req struct {
 }
 
 func (r *GenerateEd25519Req) Valid() error {
-	if r.Fpath != "" {
-		if _, err := os.Stat(r.Fpath); !errors.Is(err, os.ErrNotExist) {
-			return ErrKeyExist
-		}
-	} else {
+	if r.Fpath == "" {
 		r.Fpath = "id_ed25519"
 	}
+	if err := checkKeyNotExist(r.Fpath); err != nil {
+		return err
+	}
 
 	if r.Password != "" {
 		var err error
@@ -53,30 +51,14 @@ func GenerateEd25519(r *GenerateEd25519Req) error {
 		return errors.Wrap(err, "generate private pem")
 	}
 
-	privFile, err := os.Create(r.Fpath + ".pem")
-	if err != nil {
-		return errors.Wrap(err, "creating private key file")
-	}
-	defer privFile.Close()
-
-	if _, err = privFile.Write(privBuf); err != nil {
-		return errors.Wrap(err, "write private key file")
-	}
-
 	// Encode the public key to the PEM format
 	pubBuf, err := EncodePubToPem(pub)
 	if err != nil {
 		return errors.Wrap(err, "generate public pem")
 	}
 
-	pubFile, err := os.Create(r.Fpath + ".pub.pem")
-	if err != nil {
-		return errors.Wrap(err, "creating public key file")
-	}
-	defer pubFile.Close()
-
-	if _, err = pubFile.Write(pubBuf); err != nil {
-		return errors.Wrap(err, "write public key file")
+	if err = writeKeyPair(r.Fpath, privBuf, pubBuf); err != nil {
+		return err
 	}
 
 	fmt.Println("ed25519 key pair generated successfully!")
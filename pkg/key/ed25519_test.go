@@ -2,6 +2,7 @@ package key
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,3 +35,24 @@ func TestGenerateEd25519(t *testing.T) {
 		os.Remove("id_ed25519.pub.pem")
 	}
 }
+
+func TestGenerateEd25519File(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "id_ed25519")
+
+	err := GenerateEd25519(&GenerateEd25519Req{Fpath: fpath})
+	assert.Nil(t, err)
+
+	fi, err := os.Stat(fpath + ".pem")
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	fi, err = os.Stat(fpath + ".pub.pem")
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0644), fi.Mode().Perm())
+
+	// only pub.pem exist
+	os.Remove(fpath + ".pem")
+
+	err = GenerateEd25519(&GenerateEd25519Req{Fpath: fpath})
+	assert.Equal(t, ErrKeyExist, err)
+}
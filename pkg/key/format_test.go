@@ -0,0 +1,81 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type FormatTest struct {
+	Format   string
+	Password string
+}
+
+func TestFormat(t *testing.T) {
+	_, privE, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	privR, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	privEC, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err)
+
+	cases := []FormatTest{
+		{Format: FormatPem},
+		{Format: FormatPem, Password: "123456"},
+		{Format: FormatOpenSSH},
+		{Format: FormatOpenSSH, Password: "123456"},
+		{Format: FormatJWK},
+	}
+
+	for _, c := range cases {
+		for _, priv := range []any{privE, privR, privEC} {
+			privBuf, err := EncodePriv(priv, c.Format, []byte(c.Password), "test")
+			assert.Nil(t, err)
+			assert.Equal(t, c.Format, DetectFormat(privBuf))
+
+			ret, err := ParsePriv(privBuf, []byte(c.Password))
+			assert.Nil(t, err)
+			assert.True(t, priv.(interface{ Equal(crypto.PrivateKey) bool }).Equal(ret))
+
+			pub, err := PubFromPriv(priv)
+			assert.Nil(t, err)
+
+			pubBuf, err := EncodePub(pub, c.Format, "test")
+			assert.Nil(t, err)
+
+			retPub, err := ParsePub(pubBuf)
+			assert.Nil(t, err)
+			assert.Equal(t, pub, retPub)
+
+			retPub, err = ParseKey(pubBuf, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, pub, retPub)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	privR, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	data, err := EncodeToJWKS(pub, &privR.PublicKey)
+	assert.Nil(t, err)
+
+	ks, err := ParseJWKS(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []any{pub, &privR.PublicKey}, ks)
+
+	_, err = ParseJWK(data)
+	assert.NotNil(t, err)
+}
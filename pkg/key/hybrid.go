@@ -0,0 +1,205 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/pkg/errors"
+)
+
+// hybrid post-quantum key: ed25519 + ML-DSA-65, signature is valid only when both are valid
+const (
+	HybridSeedSize      = ed25519.SeedSize + mldsa65.SeedSize
+	HybridPublicKeySize = ed25519.PublicKeySize + mldsa65.PublicKeySize
+	HybridSignatureSize = ed25519.SignatureSize + mldsa65.SignatureSize
+
+	pemTypeHybridPrivate          = "SUPERLICENSE HYBRID PRIVATE KEY"
+	pemTypeEncryptedHybridPrivate = "ENCRYPTED SUPERLICENSE HYBRID PRIVATE KEY"
+	pemTypeHybridPublic           = "SUPERLICENSE HYBRID PUBLIC KEY"
+)
+
+type HybridPublicKey struct {
+	Ed25519 ed25519.PublicKey
+	MLDSA   *mldsa65.PublicKey
+}
+
+// Bytes ed25519 public key || ML-DSA-65 public key
+func (pub *HybridPublicKey) Bytes() []byte {
+	return append(append(make([]byte, 0, HybridPublicKeySize), pub.Ed25519...), pub.MLDSA.Bytes()...)
+}
+
+func (pub *HybridPublicKey) Equal(x crypto.PublicKey) bool {
+	v, ok := x.(*HybridPublicKey)
+	if !ok {
+		return false
+	}
+
+	return pub.Ed25519.Equal(v.Ed25519) && pub.MLDSA.Equal(v.MLDSA)
+}
+
+// Verify message is signed directly, not pre-hashed
+func (pub *HybridPublicKey) Verify(message, sig []byte) bool {
+	if len(sig) != HybridSignatureSize {
+		return false
+	}
+
+	return ed25519.Verify(pub.Ed25519, message, sig[:ed25519.SignatureSize]) &&
+		mldsa65.Verify(pub.MLDSA, message, nil, sig[ed25519.SignatureSize:])
+}
+
+func ParseHybridPublicKey(data []byte) (*HybridPublicKey, error) {
+	if len(data) != HybridPublicKeySize {
+		return nil, errors.New("invalid hybrid public key size")
+	}
+
+	pub := &HybridPublicKey{
+		Ed25519: ed25519.PublicKey(append([]byte{}, data[:ed25519.PublicKeySize]...)),
+		MLDSA:   &mldsa65.PublicKey{},
+	}
+	if err := pub.MLDSA.UnmarshalBinary(data[ed25519.PublicKeySize:]); err != nil {
+		return nil, errors.Wrap(err, "parse ML-DSA-65 public key")
+	}
+
+	return pub, nil
+}
+
+type HybridPrivateKey struct {
+	Ed25519 ed25519.PrivateKey
+	MLDSA   *mldsa65.PrivateKey
+	seed    []byte
+}
+
+func GenerateHybridKey() (*HybridPrivateKey, error) {
+	seed := make([]byte, HybridSeedSize)
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, errors.Wrap(err, "generate seed")
+	}
+
+	return NewHybridPrivateKey(seed)
+}
+
+// NewHybridPrivateKey seed is ed25519 seed || ML-DSA-65 seed
+func NewHybridPrivateKey(seed []byte) (*HybridPrivateKey, error) {
+	if len(seed) != HybridSeedSize {
+		return nil, errors.New("invalid hybrid seed size")
+	}
+
+	var mseed [mldsa65.SeedSize]byte
+	copy(mseed[:], seed[ed25519.SeedSize:])
+	_, mpriv := mldsa65.NewKeyFromSeed(&mseed)
+
+	return &HybridPrivateKey{
+		Ed25519: ed25519.NewKeyFromSeed(seed[:ed25519.SeedSize]),
+		MLDSA:   mpriv,
+		seed:    append([]byte{}, seed...),
+	}, nil
+}
+
+// Seed ed25519 seed || ML-DSA-65 seed
+func (priv *HybridPrivateKey) Seed() []byte {
+	return append([]byte{}, priv.seed...)
+}
+
+func (priv *HybridPrivateKey) Public() crypto.PublicKey {
+	return &HybridPublicKey{
+		Ed25519: priv.Ed25519.Public().(ed25519.PublicKey),
+		MLDSA:   priv.MLDSA.Public().(*mldsa65.PublicKey),
+	}
+}
+
+func (priv *HybridPrivateKey) Equal(x crypto.PrivateKey) bool {
+	v, ok := x.(*HybridPrivateKey)
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(priv.seed, v.seed) == 1
+}
+
+// Sign ed25519 signature || ML-DSA-65 signature, message is signed directly, opts.HashFunc() must be 0
+func (priv *HybridPrivateKey) Sign(_ io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 {
+		return nil, errors.New("hybrid: cannot sign hashed message")
+	}
+
+	sig := make([]byte, HybridSignatureSize)
+	copy(sig, ed25519.Sign(priv.Ed25519, message))
+
+	if err := mldsa65.SignTo(priv.MLDSA, message, nil, true, sig[ed25519.SignatureSize:]); err != nil {
+		return nil, errors.Wrap(err, "ML-DSA-65 sign")
+	}
+
+	return sig, nil
+}
+
+// hybridFingerprint x509 unsupport hybrid key, so use the raw public key
+func hybridFingerprint(pub *HybridPublicKey) string {
+	sum := sha256.Sum256(pub.Bytes())
+
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+type GenerateHybridReq struct {
+	Password  string
+	_Password []byte
+	_Salt     []byte
+	Fpath     string
+	Commont   string
+}
+
+func (r *GenerateHybridReq) Valid() error {
+	if r.Fpath == "" {
+		r.Fpath = "id_ed25519_mldsa"
+	}
+	if err := checkKeyNotExist(r.Fpath); err != nil {
+		return err
+	}
+
+	if r.Password != "" {
+		var err error
+		if r._Password, r._Salt, err = GenerateKDFKey([]byte(r.Password), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GenerateHybrid(r *GenerateHybridReq) error {
+	var err error
+	if err = r.Valid(); err != nil {
+		return err
+	}
+
+	priv, err := GenerateHybridKey()
+	if err != nil {
+		return errors.Wrap(err, "generating ed25519-mldsa private key")
+	}
+
+	// Encode the private key to the PEM format
+	privBuf, err := EncodePrivToPem(priv, r._Password, r._Salt, r.Commont)
+	if err != nil {
+		return errors.Wrap(err, "generate private pem")
+	}
+
+	// Encode the public key to the PEM format
+	pubBuf, err := EncodePubToPem(priv.Public())
+	if err != nil {
+		return errors.Wrap(err, "generate public pem")
+	}
+
+	if err = writeKeyPair(r.Fpath, privBuf, pubBuf); err != nil {
+		return err
+	}
+
+	fmt.Println("ed25519-mldsa key pair generated successfully!")
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHybrid(t *testing.T) {
+	priv, err := GenerateHybridKey()
+	assert.Nil(t, err)
+
+	password, salt, err := GenerateKDFKey([]byte("123456"), nil)
+	assert.Nil(t, err)
+
+	privBuf, err := EncodePrivToPem(priv, password, salt, "test")
+	assert.Nil(t, err)
+
+	ret, err := ParsePrivFromPem(privBuf, []byte("123456"))
+	assert.Nil(t, err)
+	assert.True(t, priv.Equal(ret))
+
+	pubBuf, err := EncodePubToPem(priv.Public())
+	assert.Nil(t, err)
+
+	pubAny, err := ParsePubFromPem(pubBuf)
+	assert.Nil(t, err)
+	pub := pubAny.(*HybridPublicKey)
+	assert.True(t, pub.Equal(priv.Public()))
+
+	info, err := ParseInfoFromPem(privBuf, []byte("123456"))
+	assert.Nil(t, err)
+	assert.Equal(t, TypeEd25519MLDSA, info.Type)
+
+	msg := []byte("superlicense")
+	sig, err := priv.Sign(nil, msg, nil)
+	assert.Nil(t, err)
+	assert.Len(t, sig, HybridSignatureSize)
+	assert.True(t, pub.Verify(msg, sig))
+
+	// both signatures must be valid
+	sig[0] ^= 1
+	assert.False(t, pub.Verify(msg, sig))
+	sig[0] ^= 1
+	sig[len(sig)-1] ^= 1
+	assert.False(t, pub.Verify(msg, sig))
+}
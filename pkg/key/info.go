@@ -0,0 +1,107 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+)
+
+type Info struct {
+	Type        string
+	Bits        int // 0 for hybrid key
+	Comment     string
+	Private     bool
+	Encrypted   bool
+	Fingerprint string // SHA256:xxx, same style as ssh-keygen -l
+}
+
+// Fingerprint is the sha256 of the PKIX DER encoded public key, or raw public key for hybrid key
+func Fingerprint(pub any) (string, error) {
+	if hpub, ok := pub.(*HybridPublicKey); ok {
+		return hybridFingerprint(hpub), nil
+	}
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal public key")
+	}
+
+	sum := sha256.Sum256(pubBytes)
+
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// PubFromPriv get public key from private key
+func PubFromPriv(priv any) (any, error) {
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unknown type of private key")
+	}
+
+	return signer.Public(), nil
+}
+
+// ParseInfoFromPem support public and private pem, password is only for encrypted private pem
+func ParseInfoFromPem(data []byte, password []byte) (*Info, error) {
+	if len(data) == 0 {
+		return nil, errors.New("no pem content")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("parse PEM block")
+	}
+
+	info := &Info{
+		Comment: block.Headers[HeaderComment],
+	}
+
+	var pub any
+	var err error
+
+	if block.Type == "PUBLIC KEY" || block.Type == pemTypeHybridPublic {
+		if pub, err = ParsePubFromPem(data); err != nil {
+			return nil, err
+		}
+	} else {
+		info.Private = true
+		info.Encrypted = x509.IsEncryptedPEMBlock(block)
+
+		priv, err := ParsePrivFromPem(data, password)
+		if err != nil {
+			return nil, err
+		}
+		if pub, err = PubFromPriv(priv); err != nil {
+			return nil, err
+		}
+	}
+
+	switch v := pub.(type) {
+	case *rsa.PublicKey:
+		info.Type = TypeRSA
+		info.Bits = v.N.BitLen()
+	case ed25519.PublicKey:
+		info.Type = TypeEd25519
+		info.Bits = len(v) * 8
+	case *ecdsa.PublicKey:
+		info.Type = TypeECDSA
+		info.Bits = v.Curve.Params().BitSize
+	case *HybridPublicKey: // no single key size
+		info.Type = TypeEd25519MLDSA
+	default:
+		return nil, errors.New("unknown type of public key")
+	}
+
+	if info.Fingerprint, err = Fingerprint(pub); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
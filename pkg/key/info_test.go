@@ -0,0 +1,44 @@
+package key
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInfoFromPem(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	password, salt, err := GenerateKDFKey([]byte("123456"), nil)
+	assert.Nil(t, err)
+
+	privBuf, err := EncodePrivToPem(priv, password, salt, "test")
+	assert.Nil(t, err)
+
+	pubBuf, err := ExportPubFromPem(privBuf, []byte("123456"))
+	assert.Nil(t, err)
+
+	fp, err := Fingerprint(pub)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(fp, "SHA256:"))
+
+	info, err := ParseInfoFromPem(privBuf, []byte("123456"))
+	assert.Nil(t, err)
+	assert.Equal(t, &Info{
+		Type:        TypeEd25519,
+		Bits:        256,
+		Comment:     "test",
+		Private:     true,
+		Encrypted:   true,
+		Fingerprint: fp,
+	}, info)
+
+	info, err = ParseInfoFromPem(pubBuf, nil)
+	assert.Nil(t, err)
+	assert.False(t, info.Private)
+	assert.Equal(t, fp, info.Fingerprint)
+}
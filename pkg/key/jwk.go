@@ -0,0 +1,403 @@
+package key
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// JWK RFC 7517, only support kty: OKP(Ed25519), EC(P-256, P-384), RSA
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// OKP, EC
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"` // only EC
+
+	// RSA
+	N  string `json:"n,omitempty"`
+	E  string `json:"e,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	Dp string `json:"dp,omitempty"`
+	Dq string `json:"dq,omitempty"`
+	Qi string `json:"qi,omitempty"`
+
+	// private part of OKP, EC and RSA
+	D string `json:"d,omitempty"`
+}
+
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// NewJWK k is public or private key, kid is the RFC 7638 thumbprint
+func NewJWK(k any) (*JWK, error) {
+	j := &JWK{}
+
+	switch v := k.(type) {
+	case ed25519.PublicKey:
+		j.Kty = "OKP"
+		j.Crv = "Ed25519"
+		j.Alg = "EdDSA"
+		j.X = b64(v)
+	case ed25519.PrivateKey:
+		j.Kty = "OKP"
+		j.Crv = "Ed25519"
+		j.Alg = "EdDSA"
+		j.X = b64(v.Public().(ed25519.PublicKey))
+		j.D = b64(v.Seed())
+	case *ecdsa.PublicKey:
+		if err := j.setEC(v); err != nil {
+			return nil, err
+		}
+	case *ecdsa.PrivateKey:
+		if err := j.setEC(&v.PublicKey); err != nil {
+			return nil, err
+		}
+
+		d, err := v.ECDH()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid ecdsa key")
+		}
+		j.D = b64(d.Bytes())
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = b64(v.N.Bytes())
+		j.E = b64(big.NewInt(int64(v.E)).Bytes())
+	case *rsa.PrivateKey:
+		if len(v.Primes) != 2 {
+			return nil, errors.New("unsupported multi-prime rsa key")
+		}
+
+		v.Precompute()
+
+		j.Kty = "RSA"
+		j.N = b64(v.N.Bytes())
+		j.E = b64(big.NewInt(int64(v.E)).Bytes())
+		j.D = b64(v.D.Bytes())
+		j.P = b64(v.Primes[0].Bytes())
+		j.Q = b64(v.Primes[1].Bytes())
+		j.Dp = b64(v.Precomputed.Dp.Bytes())
+		j.Dq = b64(v.Precomputed.Dq.Bytes())
+		j.Qi = b64(v.Precomputed.Qinv.Bytes())
+	default:
+		return nil, errors.New("unknown type of key")
+	}
+
+	var err error
+	if j.Kid, err = j.Thumbprint(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Thumbprint RFC 7638, sha256 of the required members in lexicographic order
+func (j *JWK) Thumbprint() (string, error) {
+	var data []byte
+	var err error
+
+	switch j.Kty {
+	case "OKP":
+		data, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X})
+	case "EC":
+		data, err = json.Marshal(struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y})
+	case "RSA":
+		data, err = json.Marshal(struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N})
+	default:
+		return "", errors.Errorf("unsupported jwk kty: %s", j.Kty)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "marshal jwk thumbprint")
+	}
+
+	sum := sha256.Sum256(data)
+
+	return b64(sum[:]), nil
+}
+
+// Key return private key if has private part, else public key
+func (j *JWK) Key() (any, error) {
+	switch j.Kty {
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, errors.Errorf("unsupported jwk crv: %s", j.Crv)
+		}
+
+		x, err := unb64(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid jwk x")
+		}
+
+		if j.D == "" {
+			return ed25519.PublicKey(x), nil
+		}
+
+		d, err := unb64(j.D)
+		if err != nil || len(d) != ed25519.SeedSize {
+			return nil, errors.New("invalid jwk d")
+		}
+
+		priv := ed25519.NewKeyFromSeed(d)
+		if !priv.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+			return nil, errors.New("jwk x and d mismatch")
+		}
+
+		return priv, nil
+	case "EC":
+		return j.ecKey()
+	case "RSA":
+		n, err := unb64Int(j.N)
+		if err != nil {
+			return nil, errors.New("invalid jwk n")
+		}
+		e, err := unb64Int(j.E)
+		if err != nil || !e.IsInt64() {
+			return nil, errors.New("invalid jwk e")
+		}
+
+		pub := &rsa.PublicKey{N: n, E: int(e.Int64())}
+		if j.D == "" {
+			return pub, nil
+		}
+
+		d, err := unb64Int(j.D)
+		if err != nil {
+			return nil, errors.New("invalid jwk d")
+		}
+		p, err := unb64Int(j.P)
+		if err != nil {
+			return nil, errors.New("invalid jwk p")
+		}
+		q, err := unb64Int(j.Q)
+		if err != nil {
+			return nil, errors.New("invalid jwk q")
+		}
+
+		priv := &rsa.PrivateKey{
+			PublicKey: *pub,
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err = priv.Validate(); err != nil {
+			return nil, errors.Wrap(err, "invalid jwk rsa key")
+		}
+		priv.Precompute()
+
+		return priv, nil
+	default:
+		return nil, errors.Errorf("unsupported jwk kty: %s", j.Kty)
+	}
+}
+
+// setEC x, y are fixed size big-endian coordinates
+func (j *JWK) setEC(pub *ecdsa.PublicKey) error {
+	p, err := pub.ECDH()
+	if err != nil {
+		return errors.Wrap(err, "invalid ecdsa key")
+	}
+
+	switch pub.Curve {
+	case elliptic.P256():
+		j.Crv = "P-256"
+		j.Alg = "ES256"
+	case elliptic.P384():
+		j.Crv = "P-384"
+		j.Alg = "ES384"
+	default:
+		return errors.New("unsupported ecdsa curve")
+	}
+
+	// uncompressed point: 0x04 || x || y
+	point := p.Bytes()
+	size := (len(point) - 1) / 2
+
+	j.Kty = "EC"
+	j.X = b64(point[1 : 1+size])
+	j.Y = b64(point[1+size:])
+
+	return nil
+}
+
+func (j *JWK) ecKey() (any, error) {
+	var curve elliptic.Curve
+	var ecdhCurve ecdh.Curve
+
+	switch j.Crv {
+	case "P-256":
+		curve, ecdhCurve = elliptic.P256(), ecdh.P256()
+	case "P-384":
+		curve, ecdhCurve = elliptic.P384(), ecdh.P384()
+	default:
+		return nil, errors.Errorf("unsupported jwk crv: %s", j.Crv)
+	}
+
+	x, err := unb64(j.X)
+	if err != nil {
+		return nil, errors.New("invalid jwk x")
+	}
+	y, err := unb64(j.Y)
+	if err != nil {
+		return nil, errors.New("invalid jwk y")
+	}
+
+	// check the point is on curve
+	point := append(append([]byte{4}, x...), y...)
+	if _, err = ecdhCurve.NewPublicKey(point); err != nil {
+		return nil, errors.Wrap(err, "invalid jwk ec point")
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if j.D == "" {
+		return pub, nil
+	}
+
+	d, err := unb64(j.D)
+	if err != nil {
+		return nil, errors.New("invalid jwk d")
+	}
+
+	ecdhPriv, err := ecdhCurve.NewPrivateKey(d)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid jwk d")
+	}
+	if !bytes.Equal(ecdhPriv.PublicKey().Bytes(), point) {
+		return nil, errors.New("jwk x, y and d mismatch")
+	}
+
+	return &ecdsa.PrivateKey{
+		PublicKey: *pub,
+		D:         new(big.Int).SetBytes(d),
+	}, nil
+}
+
+// EncodeToJWK k is public or private key
+func EncodeToJWK(k any) ([]byte, error) {
+	j, err := NewJWK(k)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal jwk")
+	}
+
+	return data, nil
+}
+
+// EncodeToJWKS ks are public or private keys
+func EncodeToJWKS(ks ...any) ([]byte, error) {
+	s := &JWKS{
+		Keys: make([]*JWK, 0, len(ks)),
+	}
+
+	for _, k := range ks {
+		j, err := NewJWK(k)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Keys = append(s.Keys, j)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal jwks")
+	}
+
+	return data, nil
+}
+
+// ParseJWK data is a JWK or a JWKS with only one key
+func ParseJWK(data []byte) (any, error) {
+	ks, err := ParseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(ks) != 1 {
+		return nil, errors.Errorf("need one key in jwks, but got %d", len(ks))
+	}
+
+	return ks[0], nil
+}
+
+// ParseJWKS data is a JWK or a JWKS
+func ParseJWKS(data []byte) ([]any, error) {
+	var s struct {
+		JWK
+		Keys []*JWK `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, errors.Wrap(err, "unmarshal jwk")
+	}
+
+	if s.Keys == nil {
+		s.Keys = []*JWK{&s.JWK}
+	}
+
+	ks := make([]any, 0, len(s.Keys))
+	for _, j := range s.Keys {
+		k, err := j.Key()
+		if err != nil {
+			return nil, err
+		}
+
+		ks = append(ks, k)
+	}
+
+	return ks, nil
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func unb64(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("empty")
+	}
+
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func unb64Int(s string) (*big.Int, error) {
+	data, err := unb64(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
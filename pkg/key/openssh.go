@@ -0,0 +1,94 @@
+package key
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// EncodePrivToOpenSSH password is the raw password, openssh format uses bcrypt kdf itself
+func EncodePrivToOpenSSH(priv any, password []byte, comment string) ([]byte, error) {
+	var block *pem.Block
+	var err error
+
+	if len(password) != 0 {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, comment, password)
+	} else {
+		block, err = ssh.MarshalPrivateKey(priv, comment)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal openssh private key")
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
+
+func ParsePrivFromOpenSSH(data []byte, password []byte) (any, error) {
+	var priv any
+	var err error
+
+	if len(password) != 0 {
+		priv, err = ssh.ParseRawPrivateKeyWithPassphrase(data, password)
+	} else {
+		priv, err = ssh.ParseRawPrivateKey(data)
+	}
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, errors.New("missing password")
+		}
+
+		return nil, errors.Wrap(err, "parse openssh private key")
+	}
+
+	switch v := priv.(type) {
+	case *ed25519.PrivateKey: // ssh returns pointer of ed25519 key
+		return *v, nil
+	case *rsa.PrivateKey, ed25519.PrivateKey, *ecdsa.PrivateKey:
+	default:
+		return nil, errors.New("unknown type of private key")
+	}
+
+	return priv, nil
+}
+
+// EncodePubToOpenSSH authorized_keys format, e.g. "ssh-ed25519 AAAA... comment"
+func EncodePubToOpenSSH(pub any, comment string) ([]byte, error) {
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, errors.Wrap(err, "convert to openssh public key")
+	}
+
+	data := ssh.MarshalAuthorizedKey(sshPub)
+	if comment != "" {
+		data = append(bytes.TrimSuffix(data, []byte("\n")), []byte(" "+comment+"\n")...)
+	}
+
+	return data, nil
+}
+
+func ParsePubFromOpenSSH(data []byte) (any, error) {
+	sshPub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse openssh public key")
+	}
+
+	cpub, ok := sshPub.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, errors.New("unknown type of public key")
+	}
+
+	pub := cpub.CryptoPublicKey()
+	switch pub.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, errors.New("unknown type of public key")
+	}
+
+	return pub, nil
+}
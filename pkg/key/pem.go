@@ -2,6 +2,7 @@ package key
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
@@ -18,14 +19,16 @@ const (
 )
 
 func EncodePubToPem(pub any) ([]byte, error) {
-	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
-	if err != nil {
-		return nil, errors.Wrap(err, "marshal public key")
+	pubPEM := &pem.Block{
+		Type: "PUBLIC KEY",
 	}
 
-	pubPEM := &pem.Block{
-		Type:  "PUBLIC KEY",
-		Bytes: pubBytes,
+	var err error
+	if hpub, ok := pub.(*HybridPublicKey); ok {
+		pubPEM.Type = pemTypeHybridPublic
+		pubPEM.Bytes = hpub.Bytes()
+	} else if pubPEM.Bytes, err = x509.MarshalPKIXPublicKey(pub); err != nil {
+		return nil, errors.Wrap(err, "marshal public key")
 	}
 
 	buf := bytes.NewBuffer(nil)
@@ -47,13 +50,17 @@ func ParsePubFromPem(pubPEM []byte) (any, error) {
 		return nil, errors.New("parse PEM block containing the public key")
 	}
 
+	if block.Type == pemTypeHybridPublic {
+		return ParseHybridPublicKey(block.Bytes)
+	}
+
 	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
 	if err != nil {
 		return nil, errors.Wrap(err, "parse DER encoded public key")
 	}
 
 	switch pub.(type) {
-	case *rsa.PublicKey, ed25519.PublicKey:
+	case *rsa.PublicKey, ed25519.PublicKey, *ecdsa.PublicKey:
 	default:
 		return nil, errors.New("unknown type of public key")
 	}
@@ -62,14 +69,20 @@ func ParsePubFromPem(pubPEM []byte) (any, error) {
 }
 
 func EncodePrivToPem(priv any, password, salt []byte, comment string) ([]byte, error) {
-	privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
-	if err != nil {
+	typ, encryptedTyp := "PRIVATE KEY", "ENCRYPTED PRIVATE KEY"
+
+	var privBytes []byte
+	var err error
+	if hpriv, ok := priv.(*HybridPrivateKey); ok {
+		typ, encryptedTyp = pemTypeHybridPrivate, pemTypeEncryptedHybridPrivate
+		privBytes = hpriv.Seed()
+	} else if privBytes, err = x509.MarshalPKCS8PrivateKey(priv); err != nil {
 		return nil, errors.Wrap(err, "marshal private key")
 	}
 
 	// Encode the private key to the PEM format
 	privPEM := &pem.Block{
-		Type:  "PRIVATE KEY",
+		Type:  typ,
 		Bytes: privBytes,
 	}
 	if comment != "" {
@@ -79,7 +92,7 @@ func EncodePrivToPem(priv any, password, salt []byte, comment string) ([]byte, e
 	}
 
 	if len(password) != 0 {
-		privPEM.Type = "ENCRYPTED PRIVATE KEY"
+		privPEM.Type = encryptedTyp
 
 		privPEM, err = x509.EncryptPEMBlock(rand.Reader, privPEM.Type, privPEM.Bytes, password, x509.PEMCipherAES256)
 		if err != nil {
@@ -133,16 +146,55 @@ func ParsePrivFromPem(privPEM []byte, password []byte) (any, error) {
 		}
 	}
 
+	if block.Type == pemTypeHybridPrivate || block.Type == pemTypeEncryptedHybridPrivate {
+		return NewHybridPrivateKey(privBytes)
+	}
+
 	priv, err := x509.ParsePKCS8PrivateKey(privBytes)
 	if err != nil {
 		return nil, errors.Wrap(err, "parse DER encoded private key")
 	}
 
 	switch priv.(type) {
-	case *rsa.PrivateKey, ed25519.PrivateKey:
+	case *rsa.PrivateKey, ed25519.PrivateKey, *ecdsa.PrivateKey:
 	default:
 		return nil, errors.New("unknown type of private key")
 	}
 
 	return priv, nil
 }
+
+// ExportPubFromPem derive the public pem from a private pem
+func ExportPubFromPem(privPEM []byte, password []byte) ([]byte, error) {
+	priv, err := ParsePrivFromPem(privPEM, password)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := PubFromPriv(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	return EncodePubToPem(pub)
+}
+
+// ChangePemPassword re-encrypt a private pem under newPassword and keep its comment.
+// empty newPassword means remove the password
+func ChangePemPassword(privPEM []byte, oldPassword, newPassword []byte) ([]byte, error) {
+	priv, err := ParsePrivFromPem(privPEM, oldPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(privPEM)
+
+	var password, salt []byte
+	if len(newPassword) != 0 {
+		if password, salt, err = GenerateKDFKey(newPassword, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return EncodePrivToPem(priv, password, salt, block.Headers[HeaderComment])
+}
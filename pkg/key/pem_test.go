@@ -43,3 +43,29 @@ func TestPEM(t *testing.T) {
 		assert.NotNil(t, ret)
 	}
 }
+
+func TestChangePemPassword(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	privBuf, err := EncodePrivToPem(priv, nil, nil, "test")
+	assert.Nil(t, err)
+
+	encBuf, err := ChangePemPassword(privBuf, nil, []byte("123456"))
+	assert.Nil(t, err)
+
+	_, err = ParsePrivFromPem(encBuf, nil)
+	assert.NotNil(t, err)
+
+	ret, err := ParsePrivFromPem(encBuf, []byte("123456"))
+	assert.Nil(t, err)
+	assert.Equal(t, priv, ret)
+
+	rawBuf, err := ChangePemPassword(encBuf, []byte("123456"), nil)
+	assert.Nil(t, err)
+
+	info, err := ParseInfoFromPem(rawBuf, nil)
+	assert.Nil(t, err)
+	assert.False(t, info.Encrypted)
+	assert.Equal(t, "test", info.Comment)
+}
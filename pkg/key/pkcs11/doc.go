@@ -0,0 +1,2 @@
+// Package pkcs11 is the PKCS #11 backend of key.OpenSigner, import it for register "pkcs11:" uri. need cgo.
+package pkcs11
@@ -0,0 +1,569 @@
+//go:build cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	"superlicense/pkg/key"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+)
+
+const (
+	Scheme = "pkcs11"
+)
+
+// PKCS #11 v3.0, missing in github.com/miekg/pkcs11
+const (
+	CKK_EC_EDWARDS              = 0x00000040
+	CKM_EC_EDWARDS_KEY_PAIR_GEN = 0x00001055
+	CKM_EDDSA                   = 0x00001057
+)
+
+// hash and mgf for CKM_RSA_PKCS_PSS
+var pssMechanisms = map[crypto.Hash][2]uint{
+	crypto.SHA256: {pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256},
+	crypto.SHA384: {pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384},
+	crypto.SHA512: {pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512},
+}
+
+// DigestInfo prefix for PKCS #1 v1.5 sign, same as crypto/rsa
+var hashPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+func init() {
+	key.RegisterSignerOpener(Scheme, func(uri string, password []byte) (crypto.Signer, error) {
+		return Open(uri, password)
+	})
+}
+
+// URI subset of RFC 7512, "pkcs11:module=/usr/lib/softhsm/libsofthsm2.so;token=superlicense;label=sign"
+// - module: path of PKCS #11 module
+// - token: token label, or use slot
+// - slot: slot id
+// - label/id: object label or hex id of key
+// - pin-value: pin, password of Open is preferred
+type URI struct {
+	Module string
+	Token  string
+	Slot   int
+	Label  string
+	ID     []byte
+	Pin    string
+}
+
+func ParseURI(uri string) (*URI, error) {
+	if !strings.HasPrefix(uri, Scheme+":") {
+		return nil, errors.New("invalid pkcs11 uri scheme")
+	}
+
+	u := &URI{
+		Slot: -1,
+	}
+
+	var err error
+	for _, kv := range strings.Split(strings.TrimPrefix(uri, Scheme+":"), ";") {
+		if kv == "" {
+			continue
+		}
+
+		k, v, _ := strings.Cut(kv, "=")
+		switch k {
+		case "module", "module-path":
+			u.Module = v
+		case "token":
+			u.Token = v
+		case "slot", "slot-id":
+			if u.Slot, err = strconv.Atoi(v); err != nil {
+				return nil, errors.Wrap(err, "invalid pkcs11 uri slot")
+			}
+		case "label", "object":
+			u.Label = v
+		case "id":
+			if u.ID, err = hex.DecodeString(v); err != nil {
+				return nil, errors.Wrap(err, "invalid pkcs11 uri id")
+			}
+		case "pin-value":
+			u.Pin = v
+		default:
+			return nil, errors.Errorf("unsupported pkcs11 uri attribute: %s", k)
+		}
+	}
+
+	if u.Module == "" {
+		return nil, errors.New("missing pkcs11 uri module")
+	}
+	if u.Token == "" && u.Slot < 0 {
+		return nil, errors.New("missing pkcs11 uri token or slot")
+	}
+	if u.Label == "" && len(u.ID) == 0 {
+		return nil, errors.New("missing pkcs11 uri label or id")
+	}
+
+	return u, nil
+}
+
+// moduleCtx lifecycle of module, *pkcs11.Ctx
+type moduleCtx interface {
+	Initialize() error
+	Finalize() error
+	Destroy()
+}
+
+type moduleRef struct {
+	ctx   moduleCtx
+	refs  int
+	owned bool // initialized by us, otherwise by other library in the process
+}
+
+// moduleRegistry one ctx per module path: C_Initialize is once per process, and C_Finalize tears down
+// all sessions of the module, so keys of the same module share the ctx by ref count
+type moduleRegistry struct {
+	mu  sync.Mutex
+	m   map[string]*moduleRef
+	new func(path string) moduleCtx
+}
+
+var modules = &moduleRegistry{
+	m: map[string]*moduleRef{},
+	new: func(path string) moduleCtx {
+		if ctx := pkcs11.New(path); ctx != nil {
+			return ctx
+		}
+
+		return nil
+	},
+}
+
+func (r *moduleRegistry) acquire(path string) (moduleCtx, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m := r.m[path]; m != nil {
+		m.refs++
+
+		return m.ctx, nil
+	}
+
+	ctx := r.new(path)
+	if ctx == nil {
+		return nil, errors.Errorf("load pkcs11 module: %s", path)
+	}
+
+	owned := true
+	if err := ctx.Initialize(); err != nil {
+		if !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+			ctx.Destroy()
+
+			return nil, errors.Wrap(err, "initialize pkcs11 module")
+		}
+
+		owned = false
+	}
+
+	r.m[path] = &moduleRef{ctx: ctx, refs: 1, owned: owned}
+
+	return ctx, nil
+}
+
+func (r *moduleRegistry) release(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m := r.m[path]
+	if m == nil {
+		return
+	}
+
+	if m.refs--; m.refs > 0 {
+		return
+	}
+
+	delete(r.m, path)
+	if m.owned {
+		m.ctx.Finalize()
+	}
+	m.ctx.Destroy()
+}
+
+// Signer is a crypto.Signer and crypto.Decrypter backed by private key in a PKCS #11 token
+type Signer struct {
+	mu      sync.Mutex
+	module  string
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	priv    pkcs11.ObjectHandle
+	pub     crypto.PublicKey
+}
+
+func Open(uri string, password []byte) (*Signer, error) {
+	u, err := ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	if len(password) != 0 {
+		u.Pin = string(password)
+	}
+
+	ctx, err := modules.acquire(u.Module)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Signer{
+		module: u.Module,
+		ctx:    ctx.(*pkcs11.Ctx),
+	}
+	if err = s.open(u); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Signer) open(u *URI) error {
+	slot, err := findSlot(s.ctx, u)
+	if err != nil {
+		return err
+	}
+
+	if s.session, err = s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION); err != nil {
+		return errors.Wrap(err, "open pkcs11 session")
+	}
+
+	if u.Pin != "" {
+		if err = s.ctx.Login(s.session, pkcs11.CKU_USER, u.Pin); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			return errors.Wrap(err, "login pkcs11 token")
+		}
+	}
+
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+	}
+	if u.Label != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, u.Label))
+	}
+	if len(u.ID) != 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, u.ID))
+	}
+
+	if s.priv, err = findObject(s.ctx, s.session, template); err != nil {
+		return errors.Wrap(err, "find private key")
+	}
+
+	if s.pub, err = s.loadPublic(u); err != nil {
+		return errors.Wrap(err, "load public key")
+	}
+
+	return nil
+}
+
+func (s *Signer) loadPublic(u *URI) (crypto.PublicKey, error) {
+	attrs, err := s.ctx.GetAttributeValue(s.session, s.priv, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch bytesToUint(attrs[0].Value) {
+	case pkcs11.CKK_RSA:
+		attrs, err = s.ctx.GetAttributeValue(s.session, s.priv, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+		}, nil
+	case CKK_EC_EDWARDS, pkcs11.CKK_EC:
+		// CKA_EC_POINT only in public key object
+		template := []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		}
+		if u.Label != "" {
+			template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, u.Label))
+		}
+		if len(u.ID) != 0 {
+			template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, u.ID))
+		}
+
+		pub, err := findObject(s.ctx, s.session, template)
+		if err != nil {
+			return nil, errors.Wrap(err, "find public key")
+		}
+
+		attrs, err := s.ctx.GetAttributeValue(s.session, pub, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if bytesToUint(attrs[0].Value) == CKK_EC_EDWARDS {
+			return parseEdwardsPoint(attrs[2].Value)
+		}
+
+		return parseECPoint(attrs[1].Value, attrs[2].Value)
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+}
+
+func (s *Signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+// Sign for ed25519 is pure EdDSA, for ecdsa is asn.1 signature,
+// for rsa is PKCS #1 v1.5 or PSS(opts is *rsa.PSSOptions), opts.HashFunc() == 0 means sign digest directly
+func (s *Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var m *pkcs11.Mechanism
+
+	switch s.pub.(type) {
+	case ed25519.PublicKey:
+		if opts.HashFunc() != 0 {
+			return nil, errors.New("ed25519: cannot sign hashed message")
+		}
+
+		m = pkcs11.NewMechanism(CKM_EDDSA, nil)
+	case *ecdsa.PublicKey:
+		m = pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)
+	case *rsa.PublicKey:
+		if pss, ok := opts.(*rsa.PSSOptions); ok {
+			mech, ok := pssMechanisms[pss.HashFunc()]
+			if !ok {
+				return nil, errors.New("unsupported hash function")
+			}
+
+			saltLength := pss.SaltLength
+			if saltLength == rsa.PSSSaltLengthEqualsHash || saltLength == rsa.PSSSaltLengthAuto {
+				saltLength = pss.HashFunc().Size()
+			}
+
+			m = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, pkcs11.NewPSSParams(mech[0], mech[1], uint(saltLength)))
+
+			break
+		}
+
+		if opts.HashFunc() != 0 {
+			prefix, ok := hashPrefixes[opts.HashFunc()]
+			if !ok {
+				return nil, errors.New("unsupported hash function")
+			}
+
+			digest = append(append([]byte{}, prefix...), digest...)
+		}
+
+		m = pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS, nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{m}, s.priv); err != nil {
+		return nil, errors.Wrap(err, "pkcs11 sign init")
+	}
+
+	sign, err := s.ctx.Sign(s.session, digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "pkcs11 sign")
+	}
+
+	// CKM_ECDSA returns r || s, convert to asn.1 same as crypto/ecdsa
+	if _, ok := s.pub.(*ecdsa.PublicKey); ok {
+		return marshalECDSASign(sign)
+	}
+
+	return sign, nil
+}
+
+// Decrypt only support RSA-OAEP with sha256
+func (s *Signer) Decrypt(_ io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	if _, ok := s.pub.(*rsa.PublicKey); !ok {
+		return nil, errors.New("key unsupport decrypt")
+	}
+
+	oaep, ok := opts.(*rsa.OAEPOptions)
+	if !ok || oaep.Hash != crypto.SHA256 {
+		return nil, errors.New("only support RSA-OAEP with sha256")
+	}
+
+	m := pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_OAEP, pkcs11.NewOAEPParams(pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256, pkcs11.CKZ_DATA_SPECIFIED, oaep.Label))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ctx.DecryptInit(s.session, []*pkcs11.Mechanism{m}, s.priv); err != nil {
+		return nil, errors.Wrap(err, "pkcs11 decrypt init")
+	}
+
+	plaintext, err := s.ctx.Decrypt(s.session, msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "pkcs11 decrypt")
+	}
+
+	return plaintext, nil
+}
+
+func (s *Signer) Close() error {
+	if s.ctx == nil {
+		return nil
+	}
+
+	// no Logout: login state is shared by all sessions of the token in the process,
+	// and it ends when the last session is closed
+	if s.session != 0 {
+		s.ctx.CloseSession(s.session)
+	}
+	modules.release(s.module)
+	s.ctx = nil
+
+	return nil
+}
+
+func findSlot(ctx *pkcs11.Ctx, u *URI) (uint, error) {
+	if u.Slot >= 0 {
+		return uint(u.Slot), nil
+	}
+
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, errors.Wrap(err, "get pkcs11 slots")
+	}
+
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+
+		if strings.TrimSpace(info.Label) == u.Token {
+			return slot, nil
+		}
+	}
+
+	return 0, errors.Errorf("not found pkcs11 token: %s", u.Token)
+}
+
+func findObject(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, err
+	}
+	defer ctx.FindObjectsFinal(session)
+
+	objs, _, err := ctx.FindObjects(session, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(objs) {
+	case 0:
+		return 0, errors.New("not found")
+	case 1:
+		return objs[0], nil
+	default:
+		return 0, errors.New("found more than one")
+	}
+}
+
+// parseEdwardsPoint CKA_EC_POINT is DER OCTET STRING, but some modules return raw point
+func parseEdwardsPoint(data []byte) (ed25519.PublicKey, error) {
+	if len(data) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(data), nil
+	}
+
+	var point []byte
+	if _, err := asn1.Unmarshal(data, &point); err != nil {
+		return nil, errors.Wrap(err, "parse ec point")
+	}
+	if len(point) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid ed25519 point")
+	}
+
+	return ed25519.PublicKey(point), nil
+}
+
+// parseECPoint params is DER OID of curve, point is DER OCTET STRING of uncompressed point
+func parseECPoint(params, data []byte) (*ecdsa.PublicKey, error) {
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(params, &oid); err != nil {
+		return nil, errors.Wrap(err, "parse ec params")
+	}
+
+	var curve elliptic.Curve
+	switch {
+	case oid.Equal(asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}):
+		curve = elliptic.P256()
+	case oid.Equal(asn1.ObjectIdentifier{1, 3, 132, 0, 34}):
+		curve = elliptic.P384()
+	default:
+		return nil, errors.New("unsupported ec curve")
+	}
+
+	var point []byte
+	if _, err := asn1.Unmarshal(data, &point); err != nil {
+		point = data
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+	if len(point) != 1+2*size || point[0] != 4 {
+		return nil, errors.New("invalid ec point")
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(point[1 : 1+size]),
+		Y:     new(big.Int).SetBytes(point[1+size:]),
+	}
+	if !curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, errors.New("invalid ec point")
+	}
+
+	return pub, nil
+}
+
+func marshalECDSASign(sign []byte) ([]byte, error) {
+	if len(sign) == 0 || len(sign)%2 != 0 {
+		return nil, errors.New("invalid ecdsa sign")
+	}
+
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		R: new(big.Int).SetBytes(sign[:len(sign)/2]),
+		S: new(big.Int).SetBytes(sign[len(sign)/2:]),
+	})
+}
+
+// bytesToUint CK_ULONG in native byte order(little endian on supported platforms)
+func bytesToUint(b []byte) uint {
+	var v uint
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint(b[i])
+	}
+
+	return v
+}
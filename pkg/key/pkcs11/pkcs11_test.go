@@ -0,0 +1,209 @@
+//go:build cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"testing"
+
+	"superlicense/pkg/key"
+	"superlicense/pkg/license"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseURI(t *testing.T) {
+	u, err := ParseURI("pkcs11:module=/usr/lib/softhsm/libsofthsm2.so;token=superlicense;label=sign;id=0102")
+	assert.Nil(t, err)
+	assert.Equal(t, &URI{
+		Module: "/usr/lib/softhsm/libsofthsm2.so",
+		Token:  "superlicense",
+		Slot:   -1,
+		Label:  "sign",
+		ID:     []byte{1, 2},
+	}, u)
+
+	_, err = ParseURI("pkcs11:token=superlicense;label=sign")
+	assert.NotNil(t, err)
+
+	_, err = ParseURI("pkcs11:module=x.so;label=sign")
+	assert.NotNil(t, err)
+}
+
+// TestSoftHSM need an initialized SoftHSM token, e.g.
+//
+//	softhsm2-util --init-token --free --label superlicense --so-pin 1234 --pin 123456
+//	SOFTHSM2_MODULE=/usr/lib/softhsm/libsofthsm2.so SOFTHSM2_TOKEN=superlicense SOFTHSM2_PIN=123456 go test
+func TestSoftHSM(t *testing.T) {
+	module := os.Getenv("SOFTHSM2_MODULE")
+	token := os.Getenv("SOFTHSM2_TOKEN")
+	pin := os.Getenv("SOFTHSM2_PIN")
+	if module == "" || token == "" || pin == "" {
+		t.Skip("missing SOFTHSM2_MODULE, SOFTHSM2_TOKEN, SOFTHSM2_PIN")
+	}
+
+	label, err := gonanoid.New()
+	assert.Nil(t, err)
+
+	generateKeyPair(t, module, token, pin, label)
+
+	signer, err := key.OpenSigner("pkcs11:module="+module+";token="+token+";label=sign-"+label, []byte(pin))
+	assert.Nil(t, err)
+	defer signer.(*Signer).Close()
+
+	signerR, err := key.OpenDecrypter("pkcs11:module="+module+";token="+token+";label=enc-"+label, []byte(pin))
+	assert.Nil(t, err)
+	defer signerR.(*Signer).Close()
+
+	auths := []*license.AuthV1{
+		{
+			Code:    "id",
+			Name:    "ID",
+			Content: "test",
+		},
+	}
+
+	data, err := license.BuildLicenseV1(auths, signer, signerR.(*Signer), license.LicenseV1FlagCiphertext)
+	assert.Nil(t, err)
+
+	l, err := license.ParseLicenseV1(data, signer.Public().(ed25519.PublicKey), signerR.Public().(*rsa.PublicKey))
+	assert.Nil(t, err)
+	assert.Equal(t, auths, l.Auths)
+
+	// decrypt of license req
+	msg, err := rsa.EncryptOAEP(crypto.SHA256.New(), rand.Reader, signerR.Public().(*rsa.PublicKey), []byte("test"), []byte("label"))
+	assert.Nil(t, err)
+
+	plaintext, err := signerR.Decrypt(rand.Reader, msg, &rsa.OAEPOptions{Hash: crypto.SHA256, Label: []byte("label")})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("test"), plaintext)
+
+	// two keys of one module: closing one doesn't tear down the other
+	signer2, err := Open("pkcs11:module="+module+";token="+token+";label=sign-"+label, []byte(pin))
+	assert.Nil(t, err)
+	assert.Nil(t, signer2.Close())
+
+	_, err = signer.Sign(rand.Reader, []byte("test"), crypto.Hash(0))
+	assert.Nil(t, err)
+}
+
+type fakeModuleCtx struct {
+	initErr   error
+	finalized int
+	destroyed int
+}
+
+func (c *fakeModuleCtx) Initialize() error { return c.initErr }
+func (c *fakeModuleCtx) Finalize() error   { c.finalized++; return nil }
+func (c *fakeModuleCtx) Destroy()          { c.destroyed++ }
+
+func TestModuleRegistry(t *testing.T) {
+	var created []*fakeModuleCtx
+	var initErr error
+	r := &moduleRegistry{
+		m: map[string]*moduleRef{},
+		new: func(path string) moduleCtx {
+			if path == "missing.so" {
+				return nil
+			}
+
+			c := &fakeModuleCtx{initErr: initErr}
+			created = append(created, c)
+
+			return c
+		},
+	}
+
+	// two keys of one module share the ctx
+	c1, err := r.acquire("a.so")
+	assert.Nil(t, err)
+	c2, err := r.acquire("a.so")
+	assert.Nil(t, err)
+	assert.Same(t, c1, c2)
+	assert.Len(t, created, 1)
+
+	r.release("a.so")
+	assert.Equal(t, 0, created[0].finalized)
+	r.release("a.so")
+	assert.Equal(t, 1, created[0].finalized)
+	assert.Equal(t, 1, created[0].destroyed)
+	r.release("a.so") // double close
+
+	// initialized by other library, not finalized by us
+	initErr = pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)
+	_, err = r.acquire("b.so")
+	assert.Nil(t, err)
+	r.release("b.so")
+	assert.Equal(t, 0, created[1].finalized)
+	assert.Equal(t, 1, created[1].destroyed)
+
+	initErr = pkcs11.Error(pkcs11.CKR_GENERAL_ERROR)
+	_, err = r.acquire("c.so")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, created[2].destroyed)
+	assert.Empty(t, r.m)
+
+	_, err = r.acquire("missing.so")
+	assert.NotNil(t, err)
+}
+
+// generateKeyPair token objects, session objects are destroyed when session closed
+func generateKeyPair(t *testing.T, module, token, pin, label string) {
+	ctx := pkcs11.New(module)
+	assert.NotNil(t, ctx)
+	assert.Nil(t, ctx.Initialize())
+	defer ctx.Destroy()
+	defer ctx.Finalize()
+
+	slot, err := findSlot(ctx, &URI{Token: token, Slot: -1})
+	assert.Nil(t, err)
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	assert.Nil(t, err)
+	defer ctx.CloseSession(session)
+
+	assert.Nil(t, ctx.Login(session, pkcs11.CKU_USER, pin))
+	defer ctx.Logout(session)
+
+	// OID of ed25519, curve parameters for CKM_EC_EDWARDS_KEY_PAIR_GEN
+	_, _, err = ctx.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(CKM_EC_EDWARDS_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "sign-"+label),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, []byte{0x06, 0x03, 0x2b, 0x65, 0x70}),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "sign-"+label),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		})
+	assert.Nil(t, err)
+
+	_, _, err = ctx.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ENCRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "enc-"+label),
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_DECRYPT, true),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, "enc-"+label),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		})
+	assert.Nil(t, err)
+}
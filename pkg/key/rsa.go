@@ -4,7 +4,6 @@ import (
 	"crypto/rand"
 	"crypto/rsa"
 	"fmt"
-	"os"
 
 	"github.com/pkg/errors"
 )
@@ -23,13 +22,12 @@ func (r *GenerateRSAReq) Valid() error {
 		return ErrBitsInvalidRSA
 	}
 
-	if r.Fpath != "" {
-		if _, err := os.Stat(r.Fpath); !errors.Is(err, os.ErrNotExist) {
-			return ErrKeyExist
-		}
-	} else {
+	if r.Fpath == "" {
 		r.Fpath = "id_rsa"
 	}
+	if err := checkKeyNotExist(r.Fpath); err != nil {
+		return err
+	}
 
 	if r.Password != "" {
 		var err error
@@ -59,30 +57,14 @@ func GenerateRSA(r *GenerateRSAReq) error {
 		return errors.Wrap(err, "generate private pem")
 	}
 
-	privFile, err := os.Create(r.Fpath + ".pem")
-	if err != nil {
-		return errors.Wrap(err, "creating private key file")
-	}
-	defer privFile.Close()
-
-	if _, err = privFile.Write(privBuf); err != nil {
-		return errors.Wrap(err, "write private key file")
-	}
-
 	// Encode the public key to the PEM format
 	pubBuf, err := EncodePubToPem(pub)
 	if err != nil {
 		return errors.Wrap(err, "generate public pem")
 	}
 
-	pubFile, err := os.Create(r.Fpath + ".pub.pem")
-	if err != nil {
-		return errors.Wrap(err, "creating public key file")
-	}
-	defer pubFile.Close()
-
-	if _, err = pubFile.Write(pubBuf); err != nil {
-		return errors.Wrap(err, "write public key file")
+	if err = writeKeyPair(r.Fpath, privBuf, pubBuf); err != nil {
+		return err
 	}
 
 	fmt.Println("RSA key pair generated successfully!")
@@ -0,0 +1,190 @@
+package key
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strconv"
+
+	"superlicense/pkg/lib/shamir"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	HeaderShareIndex     = "Share-Index"
+	HeaderShareThreshold = "Share-Threshold"
+	HeaderFingerprint    = "Fingerprint"
+
+	pemTypeShare          = "SUPERLICENSE KEY SHARE"
+	pemTypeEncryptedShare = "ENCRYPTED SUPERLICENSE KEY SHARE"
+)
+
+// Share a part of private key by Shamir's Secret Sharing
+type Share struct {
+	Index       int
+	Threshold   int
+	Fingerprint string // of public key, for check shares of the same key
+	Data        []byte
+}
+
+// SplitPriv split private key into n shares, any k of them can recover it
+func SplitPriv(priv any, n, k int) ([]*Share, error) {
+	pub, err := PubFromPriv(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	fp, err := Fingerprint(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal private key")
+	}
+
+	parts, err := shamir.Split(privBytes, n, k)
+	if err != nil {
+		return nil, errors.Wrap(err, "split private key")
+	}
+
+	shares := make([]*Share, 0, n)
+	for i, p := range parts {
+		shares = append(shares, &Share{
+			Index:       i + 1,
+			Threshold:   k,
+			Fingerprint: fp,
+			Data:        p,
+		})
+	}
+
+	return shares, nil
+}
+
+// CombinePriv recover private key from shares, the result is checked by Fingerprint
+func CombinePriv(shares []*Share) (any, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no share")
+	}
+
+	s0 := shares[0]
+	if len(shares) < s0.Threshold {
+		return nil, errors.Errorf("need %d shares, but got %d", s0.Threshold, len(shares))
+	}
+
+	parts := make([][]byte, 0, len(shares))
+	for _, s := range shares {
+		if s.Fingerprint != s0.Fingerprint || s.Threshold != s0.Threshold {
+			return nil, errors.Errorf("share %d is not belong to the same key", s.Index)
+		}
+
+		parts = append(parts, s.Data)
+	}
+
+	privBytes, err := shamir.Combine(parts)
+	if err != nil {
+		return nil, errors.Wrap(err, "combine shares")
+	}
+
+	priv, err := x509.ParsePKCS8PrivateKey(privBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid shares")
+	}
+
+	pub, err := PubFromPriv(priv)
+	if err != nil {
+		return nil, err
+	}
+
+	fp, err := Fingerprint(pub)
+	if err != nil {
+		return nil, err
+	}
+	if fp != s0.Fingerprint {
+		return nil, errors.New("invalid shares: fingerprint mismatch")
+	}
+
+	return priv, nil
+}
+
+// EncodeShareToPem password and salt are the same as EncodePrivToPem
+func EncodeShareToPem(s *Share, password, salt []byte) ([]byte, error) {
+	block := &pem.Block{
+		Type:  pemTypeShare,
+		Bytes: s.Data,
+	}
+
+	if len(password) != 0 {
+		var err error
+
+		block, err = x509.EncryptPEMBlock(rand.Reader, pemTypeEncryptedShare, s.Data, password, x509.PEMCipherAES256)
+		if err != nil {
+			return nil, errors.Wrap(err, "encrypt share by pem")
+		}
+		block.Headers[HeaderSalt] = hex.EncodeToString(salt)
+	}
+	if block.Headers == nil {
+		block.Headers = map[string]string{}
+	}
+
+	block.Headers[HeaderShareIndex] = strconv.Itoa(s.Index)
+	block.Headers[HeaderShareThreshold] = strconv.Itoa(s.Threshold)
+	block.Headers[HeaderFingerprint] = s.Fingerprint
+
+	buf := bytes.NewBuffer(nil)
+
+	if err := pem.Encode(buf, block); err != nil {
+		return nil, errors.Wrap(err, "encode share to pem")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func ParseShareFromPem(sharePEM []byte, password []byte) (*Share, error) {
+	if len(sharePEM) == 0 {
+		return nil, errors.New("no share pem content")
+	}
+
+	block, _ := pem.Decode(sharePEM)
+	if block == nil || (block.Type != pemTypeShare && block.Type != pemTypeEncryptedShare) {
+		return nil, errors.New("parse PEM block containing the share")
+	}
+
+	s := &Share{
+		Fingerprint: block.Headers[HeaderFingerprint],
+		Data:        block.Bytes,
+	}
+
+	var err error
+	if s.Index, err = strconv.Atoi(block.Headers[HeaderShareIndex]); err != nil {
+		return nil, errors.New("invalid share index")
+	}
+	if s.Threshold, err = strconv.Atoi(block.Headers[HeaderShareThreshold]); err != nil {
+		return nil, errors.New("invalid share threshold")
+	}
+
+	if x509.IsEncryptedPEMBlock(block) {
+		if len(password) == 0 {
+			return nil, errors.New("missing password")
+		}
+
+		salt, err := hex.DecodeString(block.Headers[HeaderSalt])
+		if err != nil {
+			return nil, errors.New("missing salt")
+		}
+
+		if password, _, err = GenerateKDFKey(password, salt); err != nil {
+			return nil, errors.Wrap(err, "generate kdf key")
+		}
+
+		if s.Data, err = x509.DecryptPEMBlock(block, password); err != nil {
+			return nil, errors.Wrap(err, "decrypt share by pem")
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,48 @@
+package key
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShare(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	shares, err := SplitPriv(priv, 5, 3)
+	assert.Nil(t, err)
+	assert.Len(t, shares, 5)
+
+	// share 2 with password
+	pems := make([][]byte, len(shares))
+	for i, s := range shares {
+		var password, salt []byte
+		if i == 1 {
+			password, salt, err = GenerateKDFKey([]byte("123456"), nil)
+			assert.Nil(t, err)
+		}
+
+		pems[i], err = EncodeShareToPem(s, password, salt)
+		assert.Nil(t, err)
+	}
+
+	_, err = ParseShareFromPem(pems[1], nil)
+	assert.NotNil(t, err)
+
+	s1, err := ParseShareFromPem(pems[1], []byte("123456"))
+	assert.Nil(t, err)
+	s3, err := ParseShareFromPem(pems[3], nil)
+	assert.Nil(t, err)
+	s4, err := ParseShareFromPem(pems[4], nil)
+	assert.Nil(t, err)
+
+	ret, err := CombinePriv([]*Share{s4, s1, s3})
+	assert.Nil(t, err)
+	assert.Equal(t, priv, ret)
+
+	_, err = CombinePriv([]*Share{s4, s1})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,68 @@
+package key
+
+import (
+	"crypto"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// SignerOpener open an external key(e.g. HSM) by uri, password is the pin
+type SignerOpener func(uri string, password []byte) (crypto.Signer, error)
+
+var (
+	signerOpenerStore = sync.Map{}
+)
+
+// RegisterSignerOpener register opener for uri with "<scheme>:" prefix
+func RegisterSignerOpener(scheme string, fn SignerOpener) {
+	_, isExist := signerOpenerStore.Load(scheme)
+	if isExist {
+		panic(errors.Errorf("double register signer opener: %s", scheme))
+	}
+
+	signerOpenerStore.Store(scheme, fn)
+}
+
+// OpenSigner p is a private key path(pem, openssh, jwk) or an uri of registered external key, e.g. "pkcs11:token=xxx;label=xxx"
+func OpenSigner(p string, password []byte) (crypto.Signer, error) {
+	if i := strings.Index(p, ":"); i > 0 {
+		if fn, ok := signerOpenerStore.Load(p[:i]); ok {
+			return fn.(SignerOpener)(p, password)
+		}
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "load private key")
+	}
+
+	priv, err := ParsePriv(data, password)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unknown type of private key")
+	}
+
+	return signer, nil
+}
+
+// OpenDecrypter same as OpenSigner, but for decrypt(e.g. rsa key)
+func OpenDecrypter(p string, password []byte) (crypto.Decrypter, error) {
+	signer, err := OpenSigner(p, password)
+	if err != nil {
+		return nil, err
+	}
+
+	d, ok := signer.(crypto.Decrypter)
+	if !ok {
+		return nil, errors.New("key unsupport decrypt")
+	}
+
+	return d, nil
+}
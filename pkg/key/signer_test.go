@@ -0,0 +1,32 @@
+package key
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenSigner(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	RegisterSignerOpener("mem", func(uri string, password []byte) (crypto.Signer, error) {
+		assert.Equal(t, "mem:test", uri)
+		assert.Equal(t, []byte("123456"), password)
+
+		return priv, nil
+	})
+
+	signer, err := OpenSigner("mem:test", []byte("123456"))
+	assert.Nil(t, err)
+	assert.Equal(t, priv.Public(), signer.Public())
+
+	_, err = OpenDecrypter("mem:test", []byte("123456"))
+	assert.NotNil(t, err)
+
+	_, err = OpenSigner("not_exist.pem", nil)
+	assert.NotNil(t, err)
+}
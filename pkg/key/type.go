@@ -1,16 +1,51 @@
 package key
 
 import (
+	"os"
+
+	"superlicense/pkg/lib/atomicfile"
+
 	"github.com/pkg/errors"
 )
 
 const (
 	TypeEd25519 = "ed25519"
 	TypeRSA     = "rsa"
+	TypeECDSA   = "ecdsa"
+
+	TypeEd25519MLDSA = "ed25519-mldsa" // hybrid post-quantum key
 )
 
 var (
-	ErrTypeInvalid    = errors.New("invalid key type")
-	ErrBitsInvalidRSA = errors.New("invalid key bits: 3072, 4096, default is 4096")
-	ErrKeyExist       = errors.New("key exist")
+	ErrTypeInvalid      = errors.New("invalid key type")
+	ErrBitsInvalidRSA   = errors.New("invalid key bits: 3072, 4096, default is 4096")
+	ErrBitsInvalidECDSA = errors.New("invalid key bits: 256, 384, default is 256")
+	ErrKeyExist         = errors.New("key exist")
 )
+
+// writeKeyPair write xxx.pem and xxx.pub.pem, remove xxx.pem when xxx.pub.pem is failed,
+// otherwise checkKeyNotExist always fails for the orphan private key
+func writeKeyPair(fpath string, privBuf, pubBuf []byte) error {
+	if err := atomicfile.WriteFile(fpath+".pem", privBuf, 0600); err != nil {
+		return errors.Wrap(err, "write private key file")
+	}
+
+	if err := atomicfile.WriteFile(fpath+".pub.pem", pubBuf, 0644); err != nil {
+		os.Remove(fpath + ".pem")
+
+		return errors.Wrap(err, "write public key file")
+	}
+
+	return nil
+}
+
+// checkKeyNotExist fpath is the prefix of xxx.pem and xxx.pub.pem
+func checkKeyNotExist(fpath string) error {
+	for _, p := range []string{fpath + ".pem", fpath + ".pub.pem"} {
+		if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+			return ErrKeyExist
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package key
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteKeyPair(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "id_test")
+
+	assert.Nil(t, writeKeyPair(fpath, []byte("priv"), []byte("pub")))
+	assert.Equal(t, ErrKeyExist, checkKeyNotExist(fpath))
+
+	// pub.pem is failed, no orphan pem
+	fpath = filepath.Join(t.TempDir(), "id_test")
+	assert.Nil(t, os.MkdirAll(filepath.Join(fpath+".pub.pem", "x"), 0700))
+
+	assert.NotNil(t, writeKeyPair(fpath, []byte("priv"), []byte("pub")))
+	_, err := os.Stat(fpath + ".pem")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,50 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFile write data to a temp file in the same dir, then rename to name.
+// So name is either the old content or the new content, never truncated.
+func WriteFile(name string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(name)
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(name)+".tmp*")
+	if err != nil {
+		return errors.Wrap(err, "create temp file")
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	// CreateTemp is 0600, chmod before write to avoid leaking
+	if err = f.Chmod(perm); err != nil {
+		return errors.Wrap(err, "chmod temp file")
+	}
+	if _, err = f.Write(data); err != nil {
+		return errors.Wrap(err, "write temp file")
+	}
+	if err = f.Sync(); err != nil {
+		return errors.Wrap(err, "sync temp file")
+	}
+	if err = f.Close(); err != nil {
+		return errors.Wrap(err, "close temp file")
+	}
+	if err = os.Rename(f.Name(), name); err != nil {
+		return errors.Wrap(err, "rename temp file")
+	}
+
+	// persist the rename, ignore error since some filesystems unsupport sync dir
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
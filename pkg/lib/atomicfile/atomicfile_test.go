@@ -0,0 +1,69 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type AtomicFileTest struct {
+	Perm os.FileMode
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "license.dat")
+
+	// perm is not affected by umask
+	cases := []*AtomicFileTest{{Perm: 0600}, {Perm: 0644}, {Perm: 0666}}
+	for _, c := range cases {
+		p := filepath.Join(dir, c.Perm.String())
+		assert.Nil(t, WriteFile(p, []byte("data"), c.Perm))
+
+		fi, err := os.Stat(p)
+		assert.Nil(t, err)
+		assert.Equal(t, c.Perm, fi.Mode().Perm())
+	}
+
+	// replace on rename, perm of new file is used
+	assert.Nil(t, os.WriteFile(name, []byte("old content, longer than new"), 0644))
+	assert.Nil(t, WriteFile(name, []byte("new"), 0600))
+
+	data, err := os.ReadFile(name)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("new"), data)
+	fi, _ := os.Stat(name)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	// an opened old file keeps the old content, the new one is another inode
+	f, err := os.Open(name)
+	assert.Nil(t, err)
+	defer f.Close()
+	assert.Nil(t, WriteFile(name, []byte("newer"), 0600))
+	old := make([]byte, 10)
+	n, _ := f.Read(old)
+	assert.Equal(t, "new", string(old[:n]))
+
+	// no temp file left
+	es, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, es, len(cases)+1)
+}
+
+func TestWriteFileFailed(t *testing.T) {
+	dir := t.TempDir()
+
+	// missing dir
+	assert.NotNil(t, WriteFile(filepath.Join(dir, "missing", "x"), []byte("data"), 0600))
+
+	// rename to a dir is failed, the temp file is removed
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "sub"), 0700))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "sub", "x"), nil, 0600))
+	assert.NotNil(t, WriteFile(filepath.Join(dir, "sub"), []byte("data"), 0600))
+
+	es, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, es, 1)
+}
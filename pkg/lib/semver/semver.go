@@ -0,0 +1,184 @@
+package semver
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Version major.minor.patch[-pre][+build], leading v is optional, build is ignored
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	Pre   string
+}
+
+func Parse(s string) (*Version, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+
+	v := &Version{}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.Pre = s[i+1:]
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return nil, errors.Errorf("invalid version: %s", s)
+	}
+
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, errors.Errorf("invalid version: %s", s)
+		}
+		*nums[i] = n
+	}
+
+	return v, nil
+}
+
+// Compare -1, 0, 1, version with pre is less than the release
+func Compare(a, b *Version) int {
+	for _, d := range []int{a.Major - b.Major, a.Minor - b.Minor, a.Patch - b.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+
+	switch {
+	case a.Pre == b.Pre:
+		return 0
+	case a.Pre == "":
+		return 1
+	case b.Pre == "":
+		return -1
+	default:
+		return comparePre(a.Pre, b.Pre)
+	}
+}
+
+// comparePre by dot separated identifiers: numeric ones numerically and lower than alphanumeric ones,
+// others in ASCII order, a shorter set is lower when all preceding are equal, e.g. rc.9 < rc.10 < rc.a
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+
+				return 1
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if n := strings.Compare(as[i], bs[i]); n != 0 {
+				return n
+			}
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	default:
+		return 0
+	}
+}
+
+type constraint struct {
+	op string
+	v  *Version
+}
+
+// Range constraints separated by comma or space, all must be satisfied, e.g. ">=1.2.0, <2.0.0"
+type Range []constraint
+
+var ops = []string{">=", "<=", "!=", ">", "<", "="}
+
+func ParseRange(s string) (Range, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(fields) == 0 {
+		return nil, errors.New("empty range")
+	}
+
+	r := make(Range, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		// bare operator, e.g. ">= 1.2.0"
+		if slices.Contains(ops, f) && i+1 < len(fields) {
+			i++
+			f += fields[i]
+		}
+
+		op := "="
+		for _, o := range ops {
+			if strings.HasPrefix(f, o) {
+				op = o
+				f = f[len(o):]
+
+				break
+			}
+		}
+
+		v, err := Parse(f)
+		if err != nil {
+			return nil, err
+		}
+
+		r = append(r, constraint{op: op, v: v})
+	}
+
+	return r, nil
+}
+
+func (r Range) Contains(v *Version) bool {
+	for _, c := range r {
+		n := Compare(v, c.v)
+
+		ok := false
+		switch c.op {
+		case ">=":
+			ok = n >= 0
+		case "<=":
+			ok = n <= 0
+		case "!=":
+			ok = n != 0
+		case ">":
+			ok = n > 0
+		case "<":
+			ok = n < 0
+		default:
+			ok = n == 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,110 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type CompareTest struct {
+	A, B string
+	Out  int
+}
+
+func TestCompare(t *testing.T) {
+	// ordered by semver 2.0.0 §11
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0-rc.9",
+		"1.0.0-rc.10",
+		"1.0.0",
+		"1.0.1",
+		"1.2.0",
+		"2.0.0",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, err := Parse(ordered[i])
+		assert.Nil(t, err)
+		b, err := Parse(ordered[i+1])
+		assert.Nil(t, err)
+
+		assert.Equal(t, -1, Compare(a, b), "%s < %s", ordered[i], ordered[i+1])
+		assert.Equal(t, 1, Compare(b, a), "%s > %s", ordered[i+1], ordered[i])
+	}
+
+	cases := []*CompareTest{
+		{A: "v1.2.3", B: "1.2.3", Out: 0},
+		{A: "1.2.3+build.1", B: "1.2.3+build.2", Out: 0},
+		{A: "1.2", B: "1.2.0", Out: 0},
+		{A: "1.0.0-rc.1", B: "1.0.0-rc.1", Out: 0},
+		{A: "1.0.0-2", B: "1.0.0-10", Out: -1},
+		{A: "1.0.0-1", B: "1.0.0-a", Out: -1},
+		{A: "10.0.0", B: "9.0.0", Out: 1},
+	}
+	for _, c := range cases {
+		a, _ := Parse(c.A)
+		b, _ := Parse(c.B)
+		assert.Equal(t, c.Out, Compare(a, b), "%s %s", c.A, c.B)
+	}
+}
+
+func TestParse(t *testing.T) {
+	v, err := Parse("v1.2.3-rc.1+build")
+	assert.Nil(t, err)
+	assert.Equal(t, &Version{Major: 1, Minor: 2, Patch: 3, Pre: "rc.1"}, v)
+
+	for _, s := range []string{"", "1.2.3.4", "a.b.c", "1.-2.3", "1..3"} {
+		_, err = Parse(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+type RangeTest struct {
+	Version string
+	Out     bool
+}
+
+func TestRange(t *testing.T) {
+	r, err := ParseRange(">=1.2.0, <2.0.0 !=1.5.0")
+	assert.Nil(t, err)
+
+	cases := []*RangeTest{
+		{Version: "1.2.0", Out: true},
+		{Version: "1.9.9", Out: true},
+		{Version: "1.5.0", Out: false},
+		{Version: "1.1.9", Out: false},
+		{Version: "2.0.0", Out: false},
+		{Version: "2.0.0-rc.1", Out: true}, // prerelease is lower than the release
+		{Version: "1.2.0-rc.1", Out: false},
+	}
+	for _, c := range cases {
+		v, err := Parse(c.Version)
+		assert.Nil(t, err)
+		assert.Equal(t, c.Out, r.Contains(v), c.Version)
+	}
+
+	r, err = ParseRange("1.0.0")
+	assert.Nil(t, err)
+	v, _ := Parse("1.0.0")
+	assert.True(t, r.Contains(v))
+
+	r, err = ParseRange(">= 1.2.0, < 2.0.0")
+	assert.Nil(t, err)
+	v, _ = Parse("1.5.0")
+	assert.True(t, r.Contains(v))
+	v, _ = Parse("2.0.0")
+	assert.False(t, r.Contains(v))
+
+	_, err = ParseRange("")
+	assert.NotNil(t, err)
+	_, err = ParseRange(">=")
+	assert.NotNil(t, err)
+	_, err = ParseRange(">=x")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,132 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Split Shamir's Secret Sharing over GF(2^8), n shares, any k of them can recover the secret.
+// The last byte of each share is its x coordinate(1..255).
+func Split(secret []byte, n, k int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("empty secret")
+	}
+	if k < 2 || k > n || n > 255 {
+		return nil, errors.New("invalid shares or threshold: 2 <= threshold <= shares <= 255")
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+
+	// coefficients of polynomial for each byte, coeffs[0] is the secret byte
+	coeffs := make([]byte, k)
+	for j, s := range secret {
+		coeffs[0] = s
+		if _, err := io.ReadFull(rand.Reader, coeffs[1:]); err != nil {
+			return nil, errors.Wrap(err, "generate coefficients")
+		}
+
+		for i := range shares {
+			shares[i][j] = evaluate(coeffs, byte(i+1))
+		}
+	}
+
+	for i := range coeffs {
+		coeffs[i] = 0
+	}
+
+	return shares, nil
+}
+
+// Combine recover the secret by lagrange interpolation at x=0, need at least threshold shares
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("need at least 2 shares")
+	}
+
+	l := len(shares[0])
+	if l < 2 {
+		return nil, errors.New("invalid share")
+	}
+
+	xs := make([]byte, len(shares))
+	for i, s := range shares {
+		if len(s) != l {
+			return nil, errors.New("shares have different length")
+		}
+
+		xs[i] = s[l-1]
+		if xs[i] == 0 {
+			return nil, errors.New("invalid share index")
+		}
+		for j := 0; j < i; j++ {
+			if xs[j] == xs[i] {
+				return nil, errors.New("double share")
+			}
+		}
+	}
+
+	secret := make([]byte, l-1)
+	for i := range xs {
+		// lagrange basis at x=0: prod(xj / (xj - xi)), sub is xor in GF(2^8)
+		basis := byte(1)
+		for j := range xs {
+			if i == j {
+				continue
+			}
+
+			basis = mul(basis, div(xs[j], xs[j]^xs[i]))
+		}
+
+		for b := range secret {
+			secret[b] ^= mul(shares[i][b], basis)
+		}
+	}
+
+	return secret, nil
+}
+
+// evaluate polynomial by horner's method
+func evaluate(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coeffs[i]
+	}
+
+	return y
+}
+
+// mul in GF(2^8) with the AES polynomial x^8 + x^4 + x^3 + x + 1, without branch on secret
+func mul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= byte(subtle.ConstantTimeByteEq(b&1, 1)) * a
+		hi := byte(subtle.ConstantTimeByteEq(a&0x80, 0x80))
+		a = a<<1 ^ hi*0x1b
+		b >>= 1
+	}
+
+	return p
+}
+
+// inverse a^254 = a^-1, a != 0
+func inverse(a byte) byte {
+	b := mul(a, a) // a^2
+	r := b
+	for i := 0; i < 6; i++ {
+		b = mul(b, b) // a^4, a^8, ..., a^128
+		r = mul(r, b)
+	}
+
+	return r
+}
+
+func div(a, b byte) byte {
+	return mul(a, inverse(b))
+}
@@ -0,0 +1,125 @@
+package shamir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ShamirTest struct {
+	Name  string
+	N, K  int
+	Pick  []int // index of shares to combine
+	IsErr bool  // Split error
+	Wrong bool  // combined but not the secret
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("super license secret key material")
+
+	cases := []*ShamirTest{
+		{Name: "k = 2", N: 5, K: 2, Pick: []int{3, 1}},
+		{Name: "k = n", N: 5, K: 5, Pick: []int{0, 1, 2, 3, 4}},
+		{Name: "more than k", N: 5, K: 3, Pick: []int{0, 1, 2, 4}},
+		{Name: "max n", N: 255, K: 3, Pick: []int{0, 128, 254}},
+		{Name: "fewer than k", N: 5, K: 3, Pick: []int{0, 1}, Wrong: true},
+		{Name: "k < 2", N: 5, K: 1, IsErr: true},
+		{Name: "k > n", N: 3, K: 4, IsErr: true},
+		{Name: "n > 255", N: 256, K: 3, IsErr: true},
+	}
+
+	for _, c := range cases {
+		shares, err := Split(secret, c.N, c.K)
+		assert.Equal(t, c.IsErr, err != nil, c.Name)
+		if err != nil {
+			continue
+		}
+		assert.Len(t, shares, c.N, c.Name)
+
+		picked := make([][]byte, 0, len(c.Pick))
+		for _, i := range c.Pick {
+			picked = append(picked, shares[i])
+		}
+
+		got, err := Combine(picked)
+		assert.Nil(t, err, c.Name)
+		if c.Wrong {
+			assert.NotEqual(t, secret, got, c.Name)
+		} else {
+			assert.Equal(t, secret, got, c.Name)
+		}
+	}
+
+	_, err := Split(nil, 3, 2)
+	assert.NotNil(t, err)
+}
+
+func TestCombineInvalid(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	assert.Nil(t, err)
+
+	// duplicate x
+	_, err = Combine([][]byte{shares[0], shares[0]})
+	assert.NotNil(t, err)
+
+	_, err = Combine([][]byte{shares[0]})
+	assert.NotNil(t, err)
+
+	_, err = Combine([][]byte{shares[0], shares[1][:3]})
+	assert.NotNil(t, err)
+
+	zero := append([]byte{}, shares[1]...)
+	zero[len(zero)-1] = 0
+	_, err = Combine([][]byte{shares[0], zero})
+	assert.NotNil(t, err)
+
+	_, err = Combine([][]byte{{1}, {2}})
+	assert.NotNil(t, err)
+}
+
+// mulSlow carry-less multiply and reduce by x^8 + x^4 + x^3 + x + 1
+func mulSlow(a, b byte) byte {
+	var p uint16
+	for i := 0; i < 8; i++ {
+		if b&(1<<i) != 0 {
+			p ^= uint16(a) << i
+		}
+	}
+	for i := 15; i >= 8; i-- {
+		if p&(1<<i) != 0 {
+			p ^= 0x11b << (i - 8)
+		}
+	}
+
+	return byte(p)
+}
+
+func TestField(t *testing.T) {
+	// known values of the AES field
+	assert.Equal(t, byte(0xc1), mul(0x57, 0x83))
+	assert.Equal(t, byte(0xfe), mul(0x57, 0x13))
+
+	for a := 0; a < 256; a++ {
+		assert.Equal(t, byte(0), mul(byte(a), 0))
+		assert.Equal(t, byte(a), mul(byte(a), 1))
+
+		for b := 0; b < 256; b++ {
+			if mul(byte(a), byte(b)) != mulSlow(byte(a), byte(b)) {
+				t.Fatalf("mul(%d, %d)", a, b)
+			}
+		}
+
+		if a == 0 {
+			continue
+		}
+
+		assert.Equal(t, byte(1), mul(byte(a), inverse(byte(a))), a)
+		assert.Equal(t, byte(a), div(mul(byte(a), 0x53), 0x53), a)
+	}
+
+	// f(x) = 3 + 5x + 7x^2
+	coeffs := []byte{3, 5, 7}
+	assert.Equal(t, byte(3), evaluate(coeffs, 0))
+	assert.Equal(t, byte(3^5^7), evaluate(coeffs, 1))
+	assert.Equal(t, 3^mul(5, 2)^mul(7, mul(2, 2)), evaluate(coeffs, 2))
+}
@@ -0,0 +1,145 @@
+package timex
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// layouts of absolute time, without timezone is in loc
+var layouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+var (
+	relativeRe = regexp.MustCompile(`^[+-]([0-9]+(y|mo|w|d|h|min|s))+$`)
+	unitRe     = regexp.MustCompile(`([0-9]+)(y|mo|w|d|h|min|s)`)
+	bareMRe    = regexp.MustCompile(`^[+-]([0-9]+[a-z]+)*[0-9]+m([0-9]|$)`) // +6m is ambiguous
+)
+
+// Parse human-friendly time, relative to now in loc(nil is time.Local):
+//   - unix timestamp: 1700000000
+//   - absolute: 2006-01-02, 2006-01-02 15:04:05, RFC 3339, with optional IANA zone suffix, e.g. "2006-01-02 15:04:05 Asia/Shanghai"
+//   - relative: +365d, +6mo, +1y6mo, -2w, units are y, mo, w, d, h, min, s, bare m is rejected for ambiguity
+//   - keyword: now, today, tomorrow, end-of-day, end-of-week, end-of-month, end-of-quarter, end-of-year, end-of is the last second of the period
+func Parse(s string, now time.Time, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, errors.New("empty time")
+	}
+
+	now = now.In(loc)
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(n, 0).In(loc), nil
+	}
+
+	if relativeRe.MatchString(s) {
+		return parseRelative(s, now), nil
+	}
+	if bareMRe.MatchString(s) {
+		return time.Time{}, errors.Errorf("ambiguous unit m: %s, use mo for months or min for minutes", s)
+	}
+
+	if t, ok := parseKeyword(s, now); ok {
+		return t, nil
+	}
+
+	// IANA zone suffix
+	if i := strings.LastIndexByte(s, ' '); i > 0 {
+		if z := s[i+1:]; strings.Contains(z, "/") || z == "UTC" {
+			if l, err := time.LoadLocation(z); err == nil {
+				loc = l
+				s = strings.TrimSpace(s[:i])
+			}
+		}
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.Errorf("invalid time: %s, need timestamp, 2006-01-02[ 15:04:05][ zone], RFC 3339, +365d, +6mo or end-of-quarter", s)
+}
+
+func parseRelative(s string, now time.Time) time.Time {
+	sign := 1
+	if s[0] == '-' {
+		sign = -1
+	}
+
+	t := now
+	for _, m := range unitRe.FindAllStringSubmatch(s[1:], -1) {
+		n, _ := strconv.Atoi(m[1])
+		n *= sign
+
+		switch m[2] {
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		case "mo":
+			t = t.AddDate(0, n, 0)
+		case "w":
+			t = t.AddDate(0, 0, 7*n)
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "h":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "min":
+			t = t.Add(time.Duration(n) * time.Minute)
+		case "s":
+			t = t.Add(time.Duration(n) * time.Second)
+		}
+	}
+
+	return t
+}
+
+func parseKeyword(s string, now time.Time) (time.Time, bool) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch strings.ToLower(s) {
+	case "now":
+		return now, true
+	case "today":
+		return day, true
+	case "tomorrow":
+		return day.AddDate(0, 0, 1), true
+	case "end-of-day":
+		return endOf(day.AddDate(0, 0, 1)), true
+	case "end-of-week": // week starts on monday
+		wd := (int(now.Weekday()) + 6) % 7
+
+		return endOf(day.AddDate(0, 0, 7-wd)), true
+	case "end-of-month":
+		return endOf(time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())), true
+	case "end-of-quarter":
+		q := (int(now.Month())-1)/3*3 + 1
+
+		return endOf(time.Date(now.Year(), time.Month(q+3), 1, 0, 0, 0, 0, now.Location())), true
+	case "end-of-year":
+		return endOf(time.Date(now.Year()+1, 1, 1, 0, 0, 0, 0, now.Location())), true
+	}
+
+	return time.Time{}, false
+}
+
+// endOf the last second before next
+func endOf(next time.Time) time.Time {
+	return next.Add(-time.Second)
+}
@@ -0,0 +1,78 @@
+package timex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ParseTest struct {
+	Name  string
+	In    string
+	Now   time.Time // zero is now of test
+	Want  time.Time
+	IsErr bool
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 12, 15, 10, 30, 0, 0, time.UTC) // monday
+	date := func(y int, m time.Month, d, h, min, s int) time.Time {
+		return time.Date(y, m, d, h, min, s, 0, time.UTC)
+	}
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.Nil(t, err)
+
+	cases := []*ParseTest{
+		{Name: "timestamp", In: "1700000000", Want: time.Unix(1700000000, 0)},
+		{Name: "date", In: "2030-01-01", Want: date(2030, 1, 1, 0, 0, 0)},
+		{Name: "datetime", In: "2030-01-01 08:00:00", Want: date(2030, 1, 1, 8, 0, 0)},
+		{Name: "rfc3339 negative offset", In: "2030-01-01T00:00:00-05:00", Want: date(2030, 1, 1, 5, 0, 0)},
+		{Name: "iana zone", In: "2030-01-01 08:00:00 Asia/Shanghai", Want: time.Date(2030, 1, 1, 8, 0, 0, 0, shanghai)},
+		{Name: "utc zone", In: "2030-01-01 08:00 UTC", Want: date(2030, 1, 1, 8, 0, 0)},
+		{Name: "days", In: "+365d", Want: date(2026, 12, 15, 10, 30, 0)},
+		{Name: "months", In: "+6mo", Want: date(2026, 6, 15, 10, 30, 0)},
+		{Name: "combined", In: "+1y6mo", Want: date(2027, 6, 15, 10, 30, 0)},
+		{Name: "minutes", In: "+90min", Want: date(2025, 12, 15, 12, 0, 0)},
+		{Name: "negative weeks", In: "-2w", Want: date(2025, 12, 1, 10, 30, 0)},
+		{Name: "negative combined", In: "-1d12h", Want: date(2025, 12, 13, 22, 30, 0)},
+		{Name: "end of day", In: "end-of-day", Want: date(2025, 12, 15, 23, 59, 59)},
+		{Name: "end of week on monday", In: "end-of-week", Want: date(2025, 12, 21, 23, 59, 59)},
+		{Name: "end of week on sunday", In: "end-of-week", Now: date(2025, 12, 28, 23, 0, 0), Want: date(2025, 12, 28, 23, 59, 59)},
+		{Name: "end of week across year", In: "end-of-week", Now: date(2025, 12, 29, 0, 0, 0), Want: date(2026, 1, 4, 23, 59, 59)},
+		{Name: "end of month in december", In: "end-of-month", Want: date(2025, 12, 31, 23, 59, 59)},
+		{Name: "end of quarter in december", In: "end-of-quarter", Want: date(2025, 12, 31, 23, 59, 59)},
+		{Name: "end of quarter on boundary", In: "end-of-quarter", Now: date(2025, 10, 1, 0, 0, 0), Want: date(2025, 12, 31, 23, 59, 59)},
+		{Name: "end of year in december", In: "END-OF-YEAR", Now: date(2025, 12, 31, 23, 59, 59), Want: date(2025, 12, 31, 23, 59, 59)},
+		{Name: "tomorrow", In: "tomorrow", Want: date(2025, 12, 16, 0, 0, 0)},
+		{Name: "bare m", In: "+6m", IsErr: true},
+		{Name: "bare m combined", In: "+1y6m", IsErr: true},
+		{Name: "empty", In: " ", IsErr: true},
+		{Name: "unknown unit", In: "+1x", IsErr: true},
+		{Name: "sign only", In: "+", IsErr: true},
+		{Name: "invalid month", In: "2030-13-01", IsErr: true},
+		{Name: "text", In: "someday", IsErr: true},
+	}
+
+	for _, c := range cases {
+		n := now
+		if !c.Now.IsZero() {
+			n = c.Now
+		}
+
+		got, err := Parse(c.In, n, time.UTC)
+		if c.IsErr {
+			assert.NotNil(t, err, c.Name)
+
+			continue
+		}
+
+		assert.Nil(t, err, c.Name)
+		assert.True(t, c.Want.Equal(got), "%s: %s", c.Name, got)
+	}
+
+	// without zone is in loc
+	got, err := Parse("2030-01-01", now, shanghai)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, shanghai).Unix(), got.Unix())
+}
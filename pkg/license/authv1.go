@@ -1,9 +1,17 @@
 package license
 
 import (
+	"bytes"
+	"fmt"
+	"net"
+	"path"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"superlicense/pkg/lib/semver"
+
 	"github.com/pkg/errors"
 )
 
@@ -15,6 +23,8 @@ type AuthV1 struct {
 	Code      string
 	Content   string `json:",omitempty"`
 	ExpiredAt int64  `json:",omitempty"` // 0, is no expire
+	Type      string `json:",omitempty"` // type of Content, see AuthV1TypeXXX, empty is untyped
+	Hidden    bool   `json:",omitempty"` // internal auth, not for customer-facing display
 	Name      string `json:",omitempty"`
 	Remark    string `json:",omitempty"`
 }
@@ -22,33 +32,54 @@ type AuthV1 struct {
 type AuthV1Check struct {
 	Requred        bool
 	Code           string
-	Name           string                    // inject to license
-	Remark         string                    // inject to license
-	Check          func(string, int64) error `json:"-"`
+	Name           string                          // inject to license
+	Remark         string                          // inject to license
+	Check          func(string, int64) error       `json:"-"`
+	Eval           func(*AuthV1, *AuthV1Env) error `json:"-"` // runtime evaluation, see EvalAuthV1s
 	RequredExpired bool
 	RequredContent bool
 	Example        string
 	Tip            string
+	Validator      *AuthV1Validator       `json:",omitempty"` // description of Check for schema(and Type), Check of declarative product
+	Type           string                 `json:",omitempty"` // declared type of Content, enforced before Check
+	Default        *string                `json:",omitempty"` // Content injected when auth is omitted, only for RequredContent
+	Hidden         bool                   `json:",omitempty"` // inject to license, hide internal auth from customer
+	I18n           map[string]*AuthV1Text `json:",omitempty"` // lang -> localized text, Name/Remark/Tip are the default
 }
 
+// GenerateAuthV1s all auths are checked, return AuthV1Errors with every problem, and the auths that validated
 func GenerateAuthV1s(checks []*AuthV1Check, auths []*AuthV1) ([]*AuthV1, error) {
+	cm := make(map[string]*AuthV1Check, len(checks))
+	for _, c := range checks {
+		cm[c.Code] = c
+	}
+
+	var errs AuthV1Errors
+
 	am := make(map[string]*AuthV1, len(auths))
 	for _, a := range auths {
 		if am[a.Code] != nil {
-			return nil, errors.Errorf("double Auth: %s", a.Code)
+			errs = append(errs, newAuthV1Error(cm[a.Code], a.Code, AuthV1ReasonDouble, nil))
+
+			continue
 		}
 
 		am[a.Code] = a
 	}
 
-	cm := make(map[string]*AuthV1Check, len(checks))
+	// inject default
 	for _, c := range checks {
-		cm[c.Code] = c
+		if am[c.Code] == nil && c.Default != nil && c.RequredContent {
+			a := &AuthV1{Code: c.Code, Content: *c.Default}
+
+			am[c.Code] = a
+			auths = append(auths[:len(auths):len(auths)], a) // copy on append, not modify auths of caller
+		}
 	}
 
-	for _, c := range cm {
+	for _, c := range checks {
 		if am[c.Code] == nil && c.Requred {
-			return nil, errors.Errorf("missing Required Auth: %s", c.Code)
+			errs = append(errs, newAuthV1Error(c, c.Code, AuthV1ReasonMissing, nil))
 		}
 	}
 
@@ -57,9 +88,15 @@ func GenerateAuthV1s(checks []*AuthV1Check, auths []*AuthV1) ([]*AuthV1, error)
 	nauths := make([]*AuthV1, 0, len(auths)) // new auths
 
 	for _, a := range auths {
+		if am[a.Code] != a { // double
+			continue
+		}
+
 		c = cm[a.Code]
 		if c == nil {
-			return nil, errors.Errorf("unsupported Auth: %s", a.Code)
+			errs = append(errs, newAuthV1Error(nil, a.Code, AuthV1ReasonUnsupported, nil))
+
+			continue
 		}
 
 		t := &AuthV1{
@@ -68,6 +105,7 @@ func GenerateAuthV1s(checks []*AuthV1Check, auths []*AuthV1) ([]*AuthV1, error)
 			Remark:    c.Remark,
 			Content:   a.Content,
 			ExpiredAt: a.ExpiredAt,
+			Hidden:    c.Hidden,
 		}
 
 		if !c.RequredExpired {
@@ -76,20 +114,49 @@ func GenerateAuthV1s(checks []*AuthV1Check, auths []*AuthV1) ([]*AuthV1, error)
 
 		if !c.RequredContent {
 			t.Content = ""
+		} else if c.Type != "" {
+			if t.Content, err = normalizeAuthV1Content(c.Type, t.Content); err != nil {
+				errs = append(errs, newAuthV1Error(c, c.Code, AuthV1ReasonInvalid, err))
+
+				continue
+			}
 		}
+		t.Type = c.Type
 
 		if c.RequredContent || c.RequredExpired {
 			if err = c.Check(t.Content, t.ExpiredAt); err != nil {
-				return nil, errors.Wrapf(err, "invalid Auth(%s)", c.Code)
+				errs = append(errs, newAuthV1Error(c, c.Code, AuthV1ReasonInvalid, err))
+
+				continue
 			}
 		}
 
 		nauths = append(nauths, t)
 	}
 
+	if len(errs) != 0 {
+		return nauths, errs // with auths that validated, e.g. for rules
+	}
+
 	return nauths, nil
 }
 
+// VisibleAuthV1s auths without Hidden, for customer-facing display
+func VisibleAuthV1s(auths []*AuthV1) []*AuthV1 {
+	vs := make([]*AuthV1, 0, len(auths))
+	for _, a := range auths {
+		if !a.Hidden {
+			vs = append(vs, a)
+		}
+	}
+
+	return vs
+}
+
+func StringPtr(s string) *string {
+	return &s
+}
+
 const (
 	AuthV1CodeIsTry     = "is_try"
 	AuthV1CodeExpiredAt = "expired_at"
@@ -100,7 +167,10 @@ func WithTry() *AuthV1Check {
 	return &AuthV1Check{
 		Requred: false,
 		Code:    AuthV1CodeIsTry,
+		Type:    AuthV1TypeBool,
+		Default: StringPtr("f"),
 		Name:    "是否试用",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Trial"}},
 		Check: func(content string, expiredAt int64) error {
 			if content != "t" && content != "f" {
 				return errors.New("need t or f")
@@ -110,6 +180,7 @@ func WithTry() *AuthV1Check {
 		},
 		RequredContent: true,
 		Example:        "t|f",
+		Validator:      &AuthV1Validator{Type: AuthV1ValidatorBool},
 	}
 }
 
@@ -117,7 +188,9 @@ func WithExpiredAt() *AuthV1Check {
 	return &AuthV1Check{
 		Requred: true,
 		Code:    AuthV1CodeExpiredAt,
+		Type:    AuthV1TypeTime,
 		Name:    "过期时间",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Expiration"}},
 		Remark:  "timestamp",
 		Check: func(content string, expiredAt int64) error {
 			now := time.Now().Unix()
@@ -128,9 +201,11 @@ func WithExpiredAt() *AuthV1Check {
 
 			return nil
 		},
+		Eval:           errExpired,
 		RequredExpired: true,
 		RequredContent: false,
 		Example:        "2006-01-02 15:04:05",
+		Tip:            "timestamp, date with timezone, RFC 3339, +365d, +6mo or end-of-quarter",
 	}
 }
 
@@ -138,7 +213,9 @@ func WithModel() *AuthV1Check {
 	return &AuthV1Check{
 		Requred: false,
 		Code:    AuthV1CodeModel,
+		Type:    AuthV1TypeString,
 		Name:    "适配型号",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Model"}},
 		Check: func(content string, expiredAt int64) error {
 			r := regexp.MustCompile(`^X[0-9]{3}$`)
 			if !r.MatchString(content) {
@@ -150,5 +227,314 @@ func WithModel() *AuthV1Check {
 		RequredContent: true,
 		Example:        "X100",
 		Tip:            `^X[0-9]{3}$`,
+		Validator:      &AuthV1Validator{Type: AuthV1ValidatorRegex, Pattern: `^X[0-9]{3}$`},
+	}
+}
+
+const (
+	AuthV1CodeMaxUsers     = "max_users"
+	AuthV1CodeFeatures     = "features"
+	AuthV1CodeVersionRange = "version_range"
+	AuthV1CodeMACs         = "macs"
+	AuthV1CodeHostname     = "hostname"
+	AuthV1CodeCPUCores     = "cpu_cores"
+	AuthV1CodeNotBefore    = "not_before"
+	AuthV1CodeCustomerID   = "customer_id"
+)
+
+// WithMaxUsers max users/seats in [min, max]
+func WithMaxUsers(min, max int64) *AuthV1Check {
+	return &AuthV1Check{
+		Requred: false,
+		Code:    AuthV1CodeMaxUsers,
+		Type:    AuthV1TypeInt,
+		Name:    "最大用户数",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Max Users"}},
+		Check: func(content string, expiredAt int64) error {
+			n, err := strconv.ParseInt(content, 10, 64)
+			if err != nil {
+				return errors.New("need integer")
+			}
+			if n < min || n > max {
+				return errors.Errorf("need in [%d, %d]", min, max)
+			}
+
+			return nil
+		},
+		Eval: func(a *AuthV1, env *AuthV1Env) error {
+			n, _ := strconv.ParseInt(a.Content, 10, 64)
+			if env.Users > n {
+				return errors.Errorf("users %d exceed %d", env.Users, n)
+			}
+
+			return nil
+		},
+		RequredContent: true,
+		Example:        strconv.FormatInt(max, 10),
+		Tip:            fmt.Sprintf("[%d, %d]", min, max),
+		Validator:      &AuthV1Validator{Type: AuthV1ValidatorInt, Min: &min, Max: &max},
+	}
+}
+
+// WithFeatures comma separated features, each must be in allowed
+func WithFeatures(allowed ...string) *AuthV1Check {
+	return &AuthV1Check{
+		Requred: false,
+		Code:    AuthV1CodeFeatures,
+		Type:    AuthV1TypeList,
+		Name:    "功能列表",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Features"}},
+		Check: func(content string, expiredAt int64) error {
+			items := splitAuthV1List(content)
+			if len(items) == 0 {
+				return errors.New("empty features")
+			}
+
+			for _, v := range items {
+				if !containsString(allowed, v) {
+					return errors.Errorf("unknown feature: %s", v)
+				}
+			}
+
+			return nil
+		},
+		Eval: func(a *AuthV1, env *AuthV1Env) error {
+			items := splitAuthV1List(a.Content)
+			for _, v := range env.Features {
+				if !containsString(items, v) {
+					return errors.Errorf("unlicensed feature: %s", v)
+				}
+			}
+
+			return nil
+		},
+		RequredContent: true,
+		Example:        strings.Join(allowed, ","),
+		Tip:            strings.Join(allowed, "|"),
+		Validator:      &AuthV1Validator{Type: AuthV1ValidatorList, Values: allowed},
+	}
+}
+
+// WithVersionRange semver range of product, e.g. ">=1.2.0, <2.0.0"
+func WithVersionRange() *AuthV1Check {
+	return &AuthV1Check{
+		Requred: false,
+		Code:    AuthV1CodeVersionRange,
+		Type:    AuthV1TypeString,
+		Name:    "版本范围",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Version Range"}},
+		Check: func(content string, expiredAt int64) error {
+			_, err := semver.ParseRange(content)
+
+			return err
+		},
+		Eval: func(a *AuthV1, env *AuthV1Env) error {
+			r, err := semver.ParseRange(a.Content)
+			if err != nil {
+				return err
+			}
+
+			if env.Version == "" {
+				return errors.New("missing version of env")
+			}
+			v, err := semver.Parse(env.Version)
+			if err != nil {
+				return errors.Wrap(err, "invalid version of env")
+			}
+
+			if !r.Contains(v) {
+				return errors.Errorf("version %s not in %s", env.Version, a.Content)
+			}
+
+			return nil
+		},
+		RequredContent: true,
+		Example:        ">=1.2.0, <2.0.0",
+	}
+}
+
+// WithMACs comma separated MACs, machine must have one of them
+func WithMACs() *AuthV1Check {
+	return &AuthV1Check{
+		Requred: false,
+		Code:    AuthV1CodeMACs,
+		Type:    AuthV1TypeList,
+		Name:    "MAC白名单",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "MAC Allowlist"}},
+		Check: func(content string, expiredAt int64) error {
+			items := splitAuthV1List(content)
+			if len(items) == 0 {
+				return errors.New("empty macs")
+			}
+
+			for _, v := range items {
+				if _, err := net.ParseMAC(v); err != nil {
+					return errors.Errorf("invalid mac: %s", v)
+				}
+			}
+
+			return nil
+		},
+		Eval: func(a *AuthV1, env *AuthV1Env) error {
+			for _, v := range splitAuthV1List(a.Content) {
+				m, _ := net.ParseMAC(v)
+
+				for _, e := range env.MACs {
+					if em, err := net.ParseMAC(e); err == nil && bytes.Equal(m, em) {
+						return nil
+					}
+				}
+			}
+
+			return errors.New("no licensed mac")
+		},
+		RequredContent: true,
+		Example:        "00:16:3e:00:00:01,00:16:3e:00:00:02",
 	}
 }
+
+// WithHostname glob of hostname, e.g. "node-*"
+func WithHostname() *AuthV1Check {
+	return &AuthV1Check{
+		Requred: false,
+		Code:    AuthV1CodeHostname,
+		Type:    AuthV1TypeString,
+		Name:    "主机名",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Hostname"}},
+		Check: func(content string, expiredAt int64) error {
+			if content == "" {
+				return errors.New("empty hostname")
+			}
+
+			_, err := path.Match(content, "")
+
+			return err
+		},
+		Eval: func(a *AuthV1, env *AuthV1Env) error {
+			if env.Hostname == "" {
+				return errors.New("missing hostname of env")
+			}
+			if ok, _ := path.Match(a.Content, env.Hostname); !ok {
+				return errors.Errorf("hostname %s not match %s", env.Hostname, a.Content)
+			}
+
+			return nil
+		},
+		RequredContent: true,
+		Example:        "node-*",
+		Tip:            "glob",
+	}
+}
+
+// WithCPUCores CPU cores limit in [1, max]
+func WithCPUCores(max int64) *AuthV1Check {
+	c := WithMaxUsers(1, max)
+	c.Code = AuthV1CodeCPUCores
+	c.Name = "CPU核数"
+	c.I18n = map[string]*AuthV1Text{LangEn: {Name: "CPU Cores"}}
+	c.Eval = func(a *AuthV1, env *AuthV1Env) error {
+		n, _ := strconv.ParseInt(a.Content, 10, 64)
+		if env.CPUCores > n {
+			return errors.Errorf("cpu cores %d exceed %d", env.CPUCores, n)
+		}
+
+		return nil
+	}
+
+	return c
+}
+
+// WithNotBefore license is valid after the date
+func WithNotBefore() *AuthV1Check {
+	return &AuthV1Check{
+		Requred: false,
+		Code:    AuthV1CodeNotBefore,
+		Type:    AuthV1TypeTime,
+		Name:    "生效时间",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Not Before"}},
+		Check: func(content string, expiredAt int64) error {
+			_, err := ParseAuthV1Date(content)
+
+			return err
+		},
+		Eval: func(a *AuthV1, env *AuthV1Env) error {
+			nb, err := ParseAuthV1Date(a.Content)
+			if err != nil {
+				return err
+			}
+
+			if env.now().Unix() < nb {
+				return errors.New("not yet valid")
+			}
+
+			return nil
+		},
+		RequredContent: true,
+		Example:        AuthV1DateLayout,
+		Tip:            "timestamp, date with timezone, RFC 3339, +365d, +6mo or end-of-quarter",
+	}
+}
+
+// WithCustomerID customer id, CustomerID of env must be the same
+func WithCustomerID() *AuthV1Check {
+	r := regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+	return &AuthV1Check{
+		Requred: false,
+		Code:    AuthV1CodeCustomerID,
+		Type:    AuthV1TypeString,
+		Name:    "客户ID",
+		I18n:    map[string]*AuthV1Text{LangEn: {Name: "Customer ID"}},
+		Check: func(content string, expiredAt int64) error {
+			if !r.MatchString(content) {
+				return errors.New("invalid customer id")
+			}
+
+			return nil
+		},
+		Eval: func(a *AuthV1, env *AuthV1Env) error {
+			if env.CustomerID == "" {
+				return errors.New("missing customer id of env")
+			}
+			if env.CustomerID != a.Content {
+				return errors.New("customer id mismatch")
+			}
+
+			return nil
+		},
+		RequredContent: true,
+		Example:        "C0001",
+		Tip:            r.String(),
+		Validator:      &AuthV1Validator{Type: AuthV1ValidatorRegex, Pattern: r.String()},
+	}
+}
+
+// ParseAuthV1Args parse "code=value" of cli/form, value is ExpiredAt(see ParseAuthV1Date) when the check only requires expired
+func ParseAuthV1Args(checks []*AuthV1Check, args []string) ([]*AuthV1, error) {
+	cm := make(map[string]*AuthV1Check, len(checks))
+	for _, c := range checks {
+		cm[c.Code] = c
+	}
+
+	auths := make([]*AuthV1, 0, len(args))
+	for _, arg := range args {
+		code, value, ok := strings.Cut(arg, "=")
+		if !ok || code == "" {
+			return nil, errors.Errorf("invalid auth: %s, need code=value", arg)
+		}
+
+		a := &AuthV1{Code: code, Content: value}
+		if c := cm[code]; c != nil && c.RequredExpired && !c.RequredContent {
+			ts, err := ParseAuthV1Date(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid auth: %s", arg)
+			}
+
+			a.Content, a.ExpiredAt = "", ts
+		}
+
+		auths = append(auths, a)
+	}
+
+	return auths, nil
+}
@@ -0,0 +1,119 @@
+package license
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuthV1Env 运行时环境, 用于校验license是否适用于当前运行环境.
+// Identity fields(Version, MACs, Hostname, CustomerID) are required by the auth bound to them: empty fails the auth.
+// Usage fields(Users, CPUCores, Features) are in use: zero/empty is nothing in use, and passes
+type AuthV1Env struct {
+	Now        time.Time // zero is time.Now()
+	Users      int64     // current users/seats
+	Version    string    // semver of product
+	MACs       []string  // NIC MACs of machine
+	Hostname   string
+	CPUCores   int64
+	Features   []string // features in use, all must be licensed
+	CustomerID string
+
+	Grace time.Duration // default grace period after expiry, overridden by grace_days of license
+	Warn  time.Duration // default warn window before expiry, overridden by warn_days of license
+
+	Clock  *ClockGuardV1 // optional, detect clock rollback, and use the highest time seen as Now
+	Ticket *TicketV1     // optional, IssuedAt is a trusted lower bound of Now, set by UseTicket, the license is expired after NotAfter
+}
+
+// NewAuthV1Env with info of current machine: now, hostname, MACs, CPU cores
+func NewAuthV1Env() *AuthV1Env {
+	env := &AuthV1Env{
+		Now:      time.Now(),
+		CPUCores: int64(runtime.NumCPU()),
+	}
+
+	env.Hostname, _ = os.Hostname()
+
+	ifs, _ := net.Interfaces()
+	for _, v := range ifs {
+		if len(v.HardwareAddr) != 0 {
+			env.MACs = append(env.MACs, v.HardwareAddr.String())
+		}
+	}
+
+	return env
+}
+
+func (env *AuthV1Env) now() time.Time {
+	now := env.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if env.Ticket != nil {
+		now = env.Ticket.Now(now)
+	}
+
+	return now
+}
+
+// EvalAuthV1s runtime evaluation of auths, auth with ExpiredAt is also checked, and is usable in grace period.
+// Auth without check is skipped, for license issued by newer product.
+// For ExpiringSoon/InGrace, see ExpiryOfAuthV1s
+func EvalAuthV1s(checks []*AuthV1Check, auths []*AuthV1, env *AuthV1Env) error {
+	if env == nil {
+		env = NewAuthV1Env()
+	}
+
+	cm := make(map[string]*AuthV1Check, len(checks))
+	for _, c := range checks {
+		cm[c.Code] = c
+	}
+
+	// grace of license
+	genv := *env
+	genv.Grace = daysOf(auths, AuthV1CodeGraceDays, env.Grace)
+	env = &genv
+
+	if env.Clock != nil {
+		now, err := env.Clock.effective(env.now())
+		if err != nil {
+			return err
+		}
+
+		env.Now = now
+	}
+	if env.Ticket != nil && env.now().Unix() > env.Ticket.NotAfter {
+		return ErrTicketExpired
+	}
+
+	for _, a := range auths {
+		if err := errExpired(a, env); err != nil {
+			return errors.Errorf("expired Auth: %s", a.Code)
+		}
+
+		c := cm[a.Code]
+		if c == nil || c.Eval == nil {
+			continue
+		}
+
+		if err := c.Eval(a, env); err != nil {
+			return errors.Wrapf(err, "unsatisfied Auth(%s)", a.Code)
+		}
+	}
+
+	return nil
+}
+
+// EvalLicenseV1 runtime evaluation by checks of registered product
+func EvalLicenseV1(name string, auths []*AuthV1, env *AuthV1Env) error {
+	l, isExist := GetLicenseV1(name)
+	if !isExist {
+		return errors.Errorf("unknown licensev1: %s", name)
+	}
+
+	return EvalAuthV1s(l.Checks(), auths, env)
+}
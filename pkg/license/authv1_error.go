@@ -0,0 +1,125 @@
+package license
+
+import (
+	stderrors "errors"
+	"strings"
+)
+
+// reason of AuthV1Error
+const (
+	AuthV1ReasonDouble      = "double"
+	AuthV1ReasonMissing     = "missing required"
+	AuthV1ReasonUnsupported = "unsupported"
+	AuthV1ReasonInvalid     = "invalid"
+	AuthV1ReasonRule        = "violate rule"
+)
+
+// AuthV1Error error of one auth, with Example/Tip of its check for showing to user
+type AuthV1Error struct {
+	Code    string `json:"code"`
+	Rule    string `json:"rule,omitempty"` // only for AuthV1ReasonRule
+	Reason  string `json:"reason"`
+	Message string `json:"message,omitempty"`
+	Example string `json:"example,omitempty"`
+	Tip     string `json:"tip,omitempty"`
+	Err     error  `json:"-"`
+}
+
+func newAuthV1Error(c *AuthV1Check, code, reason string, err error) *AuthV1Error {
+	e := &AuthV1Error{
+		Code:   code,
+		Reason: reason,
+		Err:    err,
+	}
+	if err != nil {
+		e.Message = err.Error()
+	}
+	if c != nil {
+		e.Example = c.Example
+		e.Tip = c.Tip
+	}
+
+	return e
+}
+
+func (e *AuthV1Error) Error() string {
+	var b strings.Builder
+
+	if e.Reason == AuthV1ReasonRule {
+		b.WriteString("violate Rule(" + e.Rule + ")")
+	} else {
+		b.WriteString(e.Reason + " Auth(" + e.Code + ")")
+	}
+	if e.Message != "" {
+		b.WriteString(": " + e.Message)
+	}
+	if e.Example != "" {
+		b.WriteString(", example: " + e.Example)
+	}
+	if e.Tip != "" {
+		b.WriteString(", tip: " + e.Tip)
+	}
+
+	return b.String()
+}
+
+func (e *AuthV1Error) Unwrap() error {
+	return e.Err
+}
+
+// AuthV1Errors all problems of auths, for showing at once
+type AuthV1Errors []*AuthV1Error
+
+func (es AuthV1Errors) Error() string {
+	ss := make([]string, 0, len(es))
+	for _, e := range es {
+		ss = append(ss, e.Error())
+	}
+
+	return strings.Join(ss, "\n")
+}
+
+func (es AuthV1Errors) Unwrap() []error {
+	errs := make([]error, 0, len(es))
+	for _, e := range es {
+		errs = append(errs, e)
+	}
+
+	return errs
+}
+
+// Codes codes of failed auths
+func (es AuthV1Errors) Codes() []string {
+	codes := make([]string, 0, len(es))
+	for _, e := range es {
+		if e.Code != "" && !containsString(codes, e.Code) {
+			codes = append(codes, e.Code)
+		}
+	}
+
+	return codes
+}
+
+// err return nil when empty, avoid non-nil interface of nil slice
+func (es AuthV1Errors) err() error {
+	if len(es) == 0 {
+		return nil
+	}
+
+	return es
+}
+
+// AsAuthV1Errors get AuthV1Errors from err, a single AuthV1Error is also accepted
+func AsAuthV1Errors(err error) (AuthV1Errors, bool) {
+	var es AuthV1Errors
+	if stderrors.As(err, &es) {
+		return es, true
+	}
+
+	var e *AuthV1Error
+	if stderrors.As(err, &e) {
+		return AuthV1Errors{e}, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,98 @@
+package license
+
+import (
+	"strings"
+)
+
+const (
+	LangZh = "zh"
+	LangEn = "en"
+)
+
+// AuthV1Text localized text of AuthV1Check, empty field falls back to the default
+type AuthV1Text struct {
+	Name   string `json:"name,omitempty" yaml:"name,omitempty"`
+	Remark string `json:"remark,omitempty" yaml:"remark,omitempty"`
+	Tip    string `json:"tip,omitempty" yaml:"tip,omitempty"`
+}
+
+// Text localized text by lang, e.g. "en-US" also matches "en", fallback to the default
+func (c *AuthV1Check) Text(lang string) *AuthV1Text {
+	t := &AuthV1Text{
+		Name:   c.Name,
+		Remark: c.Remark,
+		Tip:    c.Tip,
+	}
+
+	v := lookupAuthV1Text(c.I18n, lang)
+	if v == nil {
+		return t
+	}
+
+	if v.Name != "" {
+		t.Name = v.Name
+	}
+	if v.Remark != "" {
+		t.Remark = v.Remark
+	}
+	if v.Tip != "" {
+		t.Tip = v.Tip
+	}
+
+	return t
+}
+
+func lookupAuthV1Text(m map[string]*AuthV1Text, lang string) *AuthV1Text {
+	if len(m) == 0 || lang == "" {
+		return nil
+	}
+
+	lang = strings.ReplaceAll(lang, "_", "-")
+	if v := m[lang]; v != nil {
+		return v
+	}
+
+	base, _, _ := strings.Cut(lang, "-")
+
+	return m[strings.ToLower(base)]
+}
+
+// authV1EditionCheck only for text of edition auth
+var authV1EditionCheck = &AuthV1Check{
+	Code: AuthV1CodeEdition,
+	Name: "版本",
+	I18n: map[string]*AuthV1Text{LangEn: {Name: "Edition"}},
+}
+
+// LocalizeAuthV1s copy of auths with Name/Remark localized by checks, for generating or rendering.
+// Empty lang keeps the auths as is
+func LocalizeAuthV1s(checks []*AuthV1Check, auths []*AuthV1, lang string) []*AuthV1 {
+	cm := make(map[string]*AuthV1Check, len(checks)+1)
+	cm[AuthV1CodeEdition] = authV1EditionCheck
+	for _, c := range checks {
+		cm[c.Code] = c
+	}
+
+	nauths := make([]*AuthV1, 0, len(auths))
+	for _, a := range auths {
+		t := *a
+		if c := cm[a.Code]; c != nil && lang != "" {
+			text := c.Text(lang)
+			t.Name, t.Remark = text.Name, text.Remark
+		}
+
+		nauths = append(nauths, &t)
+	}
+
+	return nauths
+}
+
+// LocalizeLicenseV1 localized auths of license by checks of registered product
+func LocalizeLicenseV1(name string, auths []*AuthV1, lang string) []*AuthV1 {
+	var checks []*AuthV1Check
+	if l, isExist := GetLicenseV1(name); isExist {
+		checks = l.Checks()
+	}
+
+	return LocalizeAuthV1s(checks, auths, lang)
+}
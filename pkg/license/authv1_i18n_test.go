@@ -0,0 +1,71 @@
+package license
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type AuthV1TextTest struct {
+	Lang string
+	Name string
+}
+
+func TestAuthV1CheckText(t *testing.T) {
+	c := WithTry()
+
+	cases := []*AuthV1TextTest{
+		{Lang: "", Name: "是否试用"},
+		{Lang: LangZh, Name: "是否试用"},
+		{Lang: LangEn, Name: "Trial"},
+		{Lang: "en-US", Name: "Trial"},
+		{Lang: "en_GB", Name: "Trial"},
+		{Lang: "fr", Name: "是否试用"},
+	}
+
+	for _, v := range cases {
+		assert.Equal(t, v.Name, c.Text(v.Lang).Name, v.Lang)
+	}
+}
+
+func TestAuthV1CheckTextDerived(t *testing.T) {
+	// checks built from others have their own text
+	cases := map[*AuthV1Check]string{
+		WithCPUCores(8):    "CPU Cores",
+		WithGraceDays(30):  "Grace Days",
+		WithWarnDays(90):   "Warn Days",
+		WithTrialDays(30):  "Trial Days",
+		WithMaxUsers(1, 8): "Max Users",
+	}
+
+	for c, name := range cases {
+		assert.Equal(t, name, c.Text(LangEn).Name, c.Code)
+	}
+}
+
+func TestLocalizeAuthV1s(t *testing.T) {
+	auths, err := ValidLicenseV1(&CreateLicenseV1Req{
+		Name: "demo",
+		Lang: LangEn,
+		Auths: []*AuthV1{
+			{Code: AuthV1CodeExpiredAt, ExpiredAt: time.Now().Add(time.Hour).Unix()},
+		},
+	})
+	assert.Nil(t, err)
+
+	for _, a := range auths {
+		switch a.Code {
+		case AuthV1CodeExpiredAt:
+			assert.Equal(t, "Expiration", a.Name)
+			assert.Equal(t, "timestamp", a.Remark)
+		case AuthV1CodeIsTry:
+			assert.Equal(t, "Trial", a.Name)
+		}
+	}
+
+	// render in other lang, without modifying the origin
+	zh := LocalizeLicenseV1("demo", auths, LangZh)
+	assert.Equal(t, "Expiration", auths[0].Name)
+	assert.NotEqual(t, zh[0].Name, auths[0].Name)
+}
@@ -0,0 +1,214 @@
+package license
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuthV1Rule 跨字段校验, 可以看到全部auths, 比如"is_try=t时过期时间不能超过30天"
+type AuthV1Rule struct {
+	Name  string
+	Check func(auths map[string]*AuthV1) error
+}
+
+// LicenserV1Rules optional for LicenserV1, rules are checked after Valid by ValidLicenseV1, also on the auths
+// that validated when some auths fail. LicenserV1.Valid alone doesn't check rules
+type LicenserV1Rules interface {
+	Rules() []*AuthV1Rule
+}
+
+// CheckAuthV1Rules all rules are checked, and report all violations by AuthV1Errors
+func CheckAuthV1Rules(rules []*AuthV1Rule, auths []*AuthV1) error {
+	am := make(map[string]*AuthV1, len(auths))
+	for _, a := range auths {
+		am[a.Code] = a
+	}
+
+	var errs AuthV1Errors
+	for _, r := range rules {
+		if err := r.Check(am); err != nil {
+			e := newAuthV1Error(nil, "", AuthV1ReasonRule, err)
+			e.Rule = r.Name
+
+			if ae, ok := err.(*AuthV1Error); ok { // rule can point out the auth
+				e.Code = ae.Code
+				e.Message = ae.Message
+			}
+
+			errs = append(errs, e)
+		}
+	}
+
+	return errs.err()
+}
+
+// op of AuthV1Cond
+const (
+	AuthV1OpExists     = "exists"
+	AuthV1OpNotExists  = "not_exists"
+	AuthV1OpEq         = "eq"
+	AuthV1OpNe         = "ne"
+	AuthV1OpIn         = "in"
+	AuthV1OpContains   = "contains" // list contains value
+	AuthV1OpGt         = "gt"
+	AuthV1OpGe         = "ge"
+	AuthV1OpLt         = "lt"
+	AuthV1OpLe         = "le"
+	AuthV1OpWithinDays = "within_days" // time is not later than now + value days
+)
+
+// AuthV1Cond condition of declarative rule, gt/ge/lt/le compare int, time is compared by timestamp
+type AuthV1Cond struct {
+	Code   string   `json:"code" yaml:"code"`
+	Op     string   `json:"op" yaml:"op"`
+	Value  string   `json:"value,omitempty" yaml:"value,omitempty"`
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"` // for in
+}
+
+// AuthV1RuleDef declarative rule: when all When are matched, all Then must be matched
+type AuthV1RuleDef struct {
+	Name    string        `json:"name" yaml:"name"`
+	When    []*AuthV1Cond `json:"when,omitempty" yaml:"when,omitempty"`
+	Then    []*AuthV1Cond `json:"then" yaml:"then"`
+	Message string        `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+func (c *AuthV1Cond) Valid() error {
+	if c.Code == "" {
+		return errors.New("missing code")
+	}
+
+	switch c.Op {
+	case AuthV1OpExists, AuthV1OpNotExists:
+	case AuthV1OpEq, AuthV1OpNe, AuthV1OpContains:
+	case AuthV1OpIn:
+		if len(c.Values) == 0 {
+			return errors.Errorf("missing values: %s", c.Code)
+		}
+	case AuthV1OpGt, AuthV1OpGe, AuthV1OpLt, AuthV1OpLe, AuthV1OpWithinDays:
+		if _, err := strconv.ParseInt(c.Value, 10, 64); err != nil {
+			return errors.Errorf("need int value: %s", c.Code)
+		}
+	default:
+		return errors.Errorf("unsupported op: %s", c.Op)
+	}
+
+	return nil
+}
+
+// Match need Valid() first
+func (c *AuthV1Cond) Match(auths map[string]*AuthV1) bool {
+	a := auths[c.Code]
+
+	switch c.Op {
+	case AuthV1OpExists:
+		return a != nil
+	case AuthV1OpNotExists:
+		return a == nil
+	}
+
+	if a == nil {
+		return false
+	}
+
+	switch c.Op {
+	case AuthV1OpEq:
+		return a.Content == c.Value
+	case AuthV1OpNe:
+		return a.Content != c.Value
+	case AuthV1OpIn:
+		return containsString(c.Values, a.Content)
+	case AuthV1OpContains:
+		return containsString(splitAuthV1List(a.Content), c.Value)
+	}
+
+	n, err := authV1IntOf(a)
+	if err != nil {
+		return false
+	}
+	v, _ := strconv.ParseInt(c.Value, 10, 64)
+
+	switch c.Op {
+	case AuthV1OpGt:
+		return n > v
+	case AuthV1OpGe:
+		return n >= v
+	case AuthV1OpLt:
+		return n < v
+	case AuthV1OpLe:
+		return n <= v
+	case AuthV1OpWithinDays:
+		return n <= time.Now().AddDate(0, 0, int(v)).Unix()
+	}
+
+	return false
+}
+
+func (c *AuthV1Cond) String() string {
+	switch c.Op {
+	case AuthV1OpExists, AuthV1OpNotExists:
+		return c.Code + " " + c.Op
+	case AuthV1OpIn:
+		return c.Code + " in " + strings.Join(c.Values, "|")
+	default:
+		return c.Code + " " + c.Op + " " + c.Value
+	}
+}
+
+// authV1IntOf time is timestamp, use ExpiredAt when Content is empty
+func authV1IntOf(a *AuthV1) (int64, error) {
+	if a.Content == "" {
+		return a.ExpiredAt, nil
+	}
+	if a.Type == AuthV1TypeTime {
+		return ParseAuthV1Date(a.Content)
+	}
+
+	return strconv.ParseInt(a.Content, 10, 64)
+}
+
+func (d *AuthV1RuleDef) Valid() error {
+	if d.Name == "" {
+		return errors.New("missing rule name")
+	}
+	if len(d.Then) == 0 {
+		return errors.Errorf("missing then: %s", d.Name)
+	}
+
+	for _, c := range append(append([]*AuthV1Cond{}, d.When...), d.Then...) {
+		if err := c.Valid(); err != nil {
+			return errors.Wrapf(err, "invalid rule: %s", d.Name)
+		}
+	}
+
+	return nil
+}
+
+func (d *AuthV1RuleDef) Rule() *AuthV1Rule {
+	return &AuthV1Rule{
+		Name: d.Name,
+		Check: func(auths map[string]*AuthV1) error {
+			for _, c := range d.When {
+				if !c.Match(auths) {
+					return nil
+				}
+			}
+
+			for _, c := range d.Then {
+				if !c.Match(auths) {
+					msg := d.Message
+					if msg == "" {
+						msg = "need " + c.String()
+					}
+
+					return &AuthV1Error{Code: c.Code, Message: msg}
+				}
+			}
+
+			return nil
+		},
+	}
+}
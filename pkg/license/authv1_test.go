@@ -3,6 +3,7 @@ package license
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/stretchr/testify/assert"
@@ -15,3 +16,154 @@ func TestAuthV1Check(t *testing.T) {
 	assert.Nil(t, err)
 	spew.Dump(j)
 }
+
+type AuthV1EvalTest struct {
+	Check   *AuthV1Check
+	Content string
+	Env     *AuthV1Env
+	IsErr   bool // of Eval
+}
+
+func TestAuthV1Eval(t *testing.T) {
+	cases := []*AuthV1EvalTest{
+		{Check: WithMaxUsers(1, 100), Content: "10", Env: &AuthV1Env{Users: 10}},
+		{Check: WithMaxUsers(1, 100), Content: "10", Env: &AuthV1Env{Users: 11}, IsErr: true},
+		{Check: WithFeatures("a", "b", "c"), Content: "a,b", Env: &AuthV1Env{Features: []string{"b"}}},
+		{Check: WithFeatures("a", "b", "c"), Content: "a,b", Env: &AuthV1Env{Features: []string{"c"}}, IsErr: true},
+		{Check: WithVersionRange(), Content: ">=1.2.0, <2.0.0", Env: &AuthV1Env{Version: "v1.10.3"}},
+		{Check: WithVersionRange(), Content: ">=1.2.0, <2.0.0", Env: &AuthV1Env{Version: "2.0.1"}, IsErr: true},
+		{Check: WithVersionRange(), Content: ">=1.2.0, <2.0.0", Env: &AuthV1Env{}, IsErr: true},
+		{Check: WithMACs(), Content: "00:16:3e:00:00:01,00:16:3e:00:00:02", Env: &AuthV1Env{MACs: []string{"00-16-3E-00-00-02"}}},
+		{Check: WithMACs(), Content: "00:16:3e:00:00:01", Env: &AuthV1Env{MACs: []string{"00:16:3e:00:00:03"}}, IsErr: true},
+		{Check: WithHostname(), Content: "node-*", Env: &AuthV1Env{Hostname: "node-1"}},
+		{Check: WithHostname(), Content: "node-*", Env: &AuthV1Env{Hostname: "db-1"}, IsErr: true},
+		{Check: WithCPUCores(128), Content: "8", Env: &AuthV1Env{CPUCores: 8}},
+		{Check: WithCPUCores(128), Content: "8", Env: &AuthV1Env{CPUCores: 16}, IsErr: true},
+		{Check: WithNotBefore(), Content: "2020-01-01", Env: &AuthV1Env{}},
+		{Check: WithNotBefore(), Content: "2020-01-01", Env: &AuthV1Env{Now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.Local)}, IsErr: true},
+		{Check: WithCustomerID(), Content: "C0001", Env: &AuthV1Env{CustomerID: "C0001"}},
+		{Check: WithCustomerID(), Content: "C0001", Env: &AuthV1Env{}, IsErr: true},
+		{Check: WithHostname(), Content: "*", Env: &AuthV1Env{}, IsErr: true},
+		{Check: WithVersionRange(), Content: ">= 1.2.0, < 2.0.0", Env: &AuthV1Env{Version: "1.5.0"}},
+		{Check: WithCustomerID(), Content: "C0001", Env: &AuthV1Env{CustomerID: "C0002"}, IsErr: true},
+	}
+
+	for _, c := range cases {
+		auths, err := GenerateAuthV1s([]*AuthV1Check{c.Check}, []*AuthV1{{Code: c.Check.Code, Content: c.Content}})
+		assert.Nil(t, err, c.Check.Code)
+
+		err = EvalAuthV1s([]*AuthV1Check{c.Check}, auths, c.Env)
+		assert.Equal(t, c.IsErr, err != nil, c.Check.Code+": "+c.Content)
+	}
+}
+
+func TestAuthV1CheckInvalid(t *testing.T) {
+	for _, c := range []*AuthV1Check{WithMaxUsers(1, 100), WithFeatures("a"), WithVersionRange(), WithMACs(), WithHostname(), WithCPUCores(8), WithNotBefore(), WithCustomerID()} {
+		_, err := GenerateAuthV1s([]*AuthV1Check{c}, []*AuthV1{{Code: c.Code, Content: "!x["}})
+		assert.NotNil(t, err, c.Code)
+	}
+}
+
+func TestEvalAuthV1sExpired(t *testing.T) {
+	checks := []*AuthV1Check{WithExpiredAt()}
+	auths := []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: time.Now().Add(time.Hour).Unix()}}
+
+	assert.Nil(t, EvalAuthV1s(checks, auths, nil))
+	assert.NotNil(t, EvalAuthV1s(checks, auths, &AuthV1Env{Now: time.Now().Add(2 * time.Hour)}))
+}
+
+func TestGenerateAuthV1sErrors(t *testing.T) {
+	checks := []*AuthV1Check{WithTry(), WithExpiredAt(), WithModel(), WithMaxUsers(1, 100)}
+
+	_, err := GenerateAuthV1s(checks, []*AuthV1{
+		{Code: AuthV1CodeIsTry, Content: "x"},
+		{Code: AuthV1CodeModel, Content: "Y100"},
+		{Code: AuthV1CodeModel, Content: "X100"},
+		{Code: AuthV1CodeMaxUsers, Content: "1000"},
+		{Code: "unknown"},
+	})
+	assert.NotNil(t, err)
+
+	es, ok := AsAuthV1Errors(err)
+	assert.True(t, ok)
+	assert.Len(t, es, 6)
+	assert.ElementsMatch(t, []string{AuthV1CodeIsTry, AuthV1CodeModel, AuthV1CodeExpiredAt, AuthV1CodeMaxUsers, "unknown"}, es.Codes())
+
+	for _, e := range es {
+		switch e.Code {
+		case AuthV1CodeExpiredAt:
+			assert.Equal(t, AuthV1ReasonMissing, e.Reason)
+		case "unknown":
+			assert.Equal(t, AuthV1ReasonUnsupported, e.Reason)
+		case AuthV1CodeModel:
+			assert.Contains(t, []string{AuthV1ReasonDouble, AuthV1ReasonInvalid}, e.Reason)
+			assert.Equal(t, "X100", e.Example)
+		default:
+			assert.Equal(t, AuthV1ReasonInvalid, e.Reason)
+			assert.NotEmpty(t, e.Message)
+		}
+	}
+}
+
+func TestGenerateAuthV1sDefault(t *testing.T) {
+	internal := WithCustomerID()
+	internal.Hidden = true
+
+	checks := []*AuthV1Check{WithTry(), WithExpiredAt(), internal}
+	req := []*AuthV1{
+		{Code: AuthV1CodeExpiredAt, ExpiredAt: time.Now().Add(time.Hour).Unix()},
+		{Code: AuthV1CodeCustomerID, Content: "C0001"},
+	}
+
+	auths, err := GenerateAuthV1s(checks, req)
+	assert.Nil(t, err)
+	assert.Len(t, req, 2)
+	assert.Len(t, auths, 3)
+
+	l := &LicenseV1{Auths: auths}
+
+	b, err := l.Bool(AuthV1CodeIsTry)
+	assert.Nil(t, err)
+	assert.False(t, b)
+
+	vs := l.VisibleAuths()
+	assert.Len(t, vs, 2)
+	for _, a := range vs {
+		assert.NotEqual(t, AuthV1CodeCustomerID, a.Code)
+	}
+
+	s, err := l.Text(AuthV1CodeCustomerID)
+	assert.Nil(t, err)
+	assert.Equal(t, "C0001", s)
+
+	// explicit value is not overwritten by default
+	auths, err = GenerateAuthV1s(checks, append(req, &AuthV1{Code: AuthV1CodeIsTry, Content: "t"}))
+	assert.Nil(t, err)
+	assert.Len(t, auths, 3)
+}
+
+func TestAuthV1Expires(t *testing.T) {
+	checks := []*AuthV1Check{WithExpiredAt()}
+
+	auths, err := ValidLicenseV1(&CreateLicenseV1Req{Name: "demo", Expires: map[string]string{AuthV1CodeExpiredAt: "+365d"}})
+	assert.Nil(t, err)
+	assert.Equal(t, AuthV1CodeExpiredAt, auths[0].Code)
+	assert.InDelta(t, time.Now().AddDate(1, 0, 0).Unix(), auths[0].ExpiredAt, 5)
+
+	// override ExpiredAt, auths of caller aren't modified
+	req := []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: 1}}
+	auths, err = ValidLicenseV1(&CreateLicenseV1Req{Name: "demo", Auths: req, Expires: map[string]string{AuthV1CodeExpiredAt: "end-of-year"}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), req[0].ExpiredAt)
+	assert.Equal(t, time.December, time.Unix(auths[0].ExpiredAt, 0).Month())
+
+	auths, err = ParseAuthV1Args(checks, []string{"expired_at=2030-01-01 08:00:00 Asia/Shanghai"})
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC).Unix(), auths[0].ExpiredAt)
+
+	_, err = ValidLicenseV1(&CreateLicenseV1Req{Name: "demo", Expires: map[string]string{AuthV1CodeExpiredAt: "-1d"}})
+	assert.NotNil(t, err)
+	_, err = ValidLicenseV1(&CreateLicenseV1Req{Name: "demo", Expires: map[string]string{AuthV1CodeExpiredAt: "someday"}})
+	es, _ := AsAuthV1Errors(err)
+	assert.Equal(t, AuthV1ReasonInvalid, es[0].Reason)
+}
@@ -0,0 +1,208 @@
+package license
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// type of AuthV1.Content, Content is still string in license for compatibility, but normalized by type:
+// - bool: t|f, input also can be true|false|1|0
+// - int: decimal int64
+// - string: as is
+// - list: comma separated, items are trimmed
+// - time: unix timestamp, input also can be AuthV1DateLayout
+const (
+	AuthV1TypeBool   = "bool"
+	AuthV1TypeInt    = "int"
+	AuthV1TypeString = "string"
+	AuthV1TypeList   = "list"
+	AuthV1TypeTime   = "time"
+)
+
+var (
+	ErrAuthV1NotFound = errors.New("auth not found")
+	ErrAuthV1Expired  = errors.New("auth expired")
+)
+
+// isAuthV1Type empty is untyped
+func isAuthV1Type(typ string) bool {
+	switch typ {
+	case "", AuthV1TypeBool, AuthV1TypeInt, AuthV1TypeString, AuthV1TypeList, AuthV1TypeTime:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizeAuthV1Content check content by type, and return the normalized content
+func normalizeAuthV1Content(typ, content string) (string, error) {
+	switch typ {
+	case "", AuthV1TypeString:
+		return content, nil
+	case AuthV1TypeBool:
+		b, err := parseAuthV1Bool(content)
+		if err != nil {
+			return "", err
+		}
+		if b {
+			return "t", nil
+		}
+
+		return "f", nil
+	case AuthV1TypeInt:
+		n, err := strconv.ParseInt(strings.TrimSpace(content), 10, 64)
+		if err != nil {
+			return "", errors.New("need int")
+		}
+
+		return strconv.FormatInt(n, 10), nil
+	case AuthV1TypeList:
+		return strings.Join(splitAuthV1List(content), ","), nil
+	case AuthV1TypeTime:
+		ts, err := ParseAuthV1Date(strings.TrimSpace(content))
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.FormatInt(ts, 10), nil
+	default:
+		return "", errors.Errorf("unsupported type: %s", typ)
+	}
+}
+
+func parseAuthV1Bool(s string) (bool, error) {
+	switch strings.TrimSpace(s) {
+	case "t", "T", "true", "TRUE", "True", "1":
+		return true, nil
+	case "f", "F", "false", "FALSE", "False", "0":
+		return false, nil
+	default:
+		return false, errors.New("need bool: t|f")
+	}
+}
+
+func (a *AuthV1) checkType(typ string) error {
+	if a.Type != "" && a.Type != typ {
+		return errors.Errorf("type of Auth(%s) is %s, not %s", a.Code, a.Type, typ)
+	}
+
+	return nil
+}
+
+func (a *AuthV1) Bool() (bool, error) {
+	if err := a.checkType(AuthV1TypeBool); err != nil {
+		return false, err
+	}
+
+	return parseAuthV1Bool(a.Content)
+}
+
+func (a *AuthV1) Int() (int64, error) {
+	if err := a.checkType(AuthV1TypeInt); err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(a.Content, 10, 64)
+}
+
+func (a *AuthV1) List() ([]string, error) {
+	if err := a.checkType(AuthV1TypeList); err != nil {
+		return nil, err
+	}
+
+	return splitAuthV1List(a.Content), nil
+}
+
+// Time use ExpiredAt when Content is empty, e.g. expired_at
+func (a *AuthV1) Time() (time.Time, error) {
+	if err := a.checkType(AuthV1TypeTime); err != nil {
+		return time.Time{}, err
+	}
+
+	ts := a.ExpiredAt
+	if a.Content != "" {
+		var err error
+		if ts, err = ParseAuthV1Date(a.Content); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	return time.Unix(ts, 0), nil
+}
+
+func (l *LicenseV1) Auth(code string) *AuthV1 {
+	for _, a := range l.Auths {
+		if a.Code == code {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// VisibleAuths auths without Hidden, runtime still can read hidden auths by Auth(code)
+func (l *LicenseV1) VisibleAuths() []*AuthV1 {
+	return VisibleAuthV1s(l.Auths)
+}
+
+func (l *LicenseV1) authOf(code string) (*AuthV1, error) {
+	a := l.Auth(code)
+	if a == nil {
+		return nil, errors.Wrap(ErrAuthV1NotFound, code)
+	}
+
+	return a, nil
+}
+
+func (l *LicenseV1) Bool(code string) (bool, error) {
+	a, err := l.authOf(code)
+	if err != nil {
+		return false, err
+	}
+
+	return a.Bool()
+}
+
+func (l *LicenseV1) Int(code string) (int64, error) {
+	a, err := l.authOf(code)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.Int()
+}
+
+// Text content of string auth, LicenseV1 isn't a fmt.Stringer
+func (l *LicenseV1) Text(code string) (string, error) {
+	a, err := l.authOf(code)
+	if err != nil {
+		return "", err
+	}
+
+	if err = a.checkType(AuthV1TypeString); err != nil {
+		return "", err
+	}
+
+	return a.Content, nil
+}
+
+func (l *LicenseV1) List(code string) ([]string, error) {
+	a, err := l.authOf(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.List()
+}
+
+func (l *LicenseV1) Time(code string) (time.Time, error) {
+	a, err := l.authOf(code)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return a.Time()
+}
@@ -0,0 +1,86 @@
+package license
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type AuthV1TypeTest struct {
+	Type    string
+	Content string
+	Expect  string
+	IsErr   bool
+}
+
+func TestNormalizeAuthV1Content(t *testing.T) {
+	cases := []*AuthV1TypeTest{
+		{Type: AuthV1TypeBool, Content: "true", Expect: "t"},
+		{Type: AuthV1TypeBool, Content: "0", Expect: "f"},
+		{Type: AuthV1TypeBool, Content: "yes", IsErr: true},
+		{Type: AuthV1TypeInt, Content: " 42", Expect: "42"},
+		{Type: AuthV1TypeInt, Content: "4.2", IsErr: true},
+		{Type: AuthV1TypeList, Content: "a, b,,c ", Expect: "a,b,c"},
+		{Type: AuthV1TypeTime, Content: "1700000000", Expect: "1700000000"},
+		{Type: AuthV1TypeTime, Content: "01/01/2023", IsErr: true},
+		{Type: AuthV1TypeTime, Content: "2023/01/01", Expect: strconv.FormatInt(time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local).Unix(), 10)},
+		{Type: AuthV1TypeString, Content: " x ", Expect: " x "},
+		{Type: "float", Content: "1", IsErr: true},
+	}
+
+	for _, c := range cases {
+		v, err := normalizeAuthV1Content(c.Type, c.Content)
+		assert.Equal(t, c.IsErr, err != nil, c.Type+": "+c.Content)
+		assert.Equal(t, c.Expect, v, c.Type+": "+c.Content)
+	}
+}
+
+func TestLicenseV1TypedAuths(t *testing.T) {
+	checks := []*AuthV1Check{WithTry(), WithExpiredAt(), WithMaxUsers(1, 100), WithFeatures("a", "b", "c"), WithModel()}
+	expiredAt := time.Now().Add(time.Hour).Unix()
+
+	auths, err := GenerateAuthV1s(checks, []*AuthV1{
+		{Code: AuthV1CodeIsTry, Content: "true"},
+		{Code: AuthV1CodeExpiredAt, ExpiredAt: expiredAt},
+		{Code: AuthV1CodeMaxUsers, Content: "10"},
+		{Code: AuthV1CodeFeatures, Content: "a, c"},
+		{Code: AuthV1CodeModel, Content: "X100"},
+	})
+	assert.Nil(t, err)
+
+	l := &LicenseV1{Auths: auths}
+
+	b, err := l.Bool(AuthV1CodeIsTry)
+	assert.Nil(t, err)
+	assert.True(t, b)
+
+	n, err := l.Int(AuthV1CodeMaxUsers)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), n)
+
+	fs, err := l.List(AuthV1CodeFeatures)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "c"}, fs)
+
+	tm, err := l.Time(AuthV1CodeExpiredAt)
+	assert.Nil(t, err)
+	assert.Equal(t, expiredAt, tm.Unix())
+
+	s, err := l.Text(AuthV1CodeModel)
+	assert.Nil(t, err)
+	assert.Equal(t, "X100", s)
+
+	_, err = l.Int(AuthV1CodeIsTry) // type mismatch
+	assert.NotNil(t, err)
+
+	_, err = l.Bool("not_exist")
+	assert.ErrorIs(t, err, ErrAuthV1NotFound)
+
+	// untyped auth of old license
+	old := &LicenseV1{Auths: []*AuthV1{{Code: AuthV1CodeIsTry, Content: "f"}}}
+	b, err = old.Bool(AuthV1CodeIsTry)
+	assert.Nil(t, err)
+	assert.False(t, b)
+}
@@ -0,0 +1,193 @@
+package license
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"superlicense/pkg/lib/atomicfile"
+	"superlicense/pkg/mark"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	ClockV1Version          = 1
+	ClockV1DefaultTolerance = 10 * time.Minute // ntp adjust, timezone change is not a rollback(unix time)
+	ClockV1DefaultStep      = time.Minute      // avoid writing state file on every check
+)
+
+var (
+	ErrClockRollback      = errors.New("clock rollback")
+	ErrClockStateTampered = errors.New("clock state tampered")
+	ErrClockStateUnsaved  = errors.New("clock state unsaved") // e.g. read-only or full disk, the time is still effective
+
+	clockV1Info = []byte("superlicense clock v1")
+)
+
+// clockV1State state file, Mac is hmac-sha256 of the other fields
+type clockV1State struct {
+	Version  int    `json:"version"`
+	LastSeen int64  `json:"last_seen"` // highest unix time seen
+	Mac      []byte `json:"mac"`
+}
+
+func (s *clockV1State) mac(key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	json.NewEncoder(h).Encode([]int64{int64(s.Version), s.LastSeen})
+
+	return h.Sum(nil)
+}
+
+// ClockGuardV1 detect clock rollback for offline expiry enforcement, by a tamper-evident state file
+// of the highest time seen. The key is derived from sign of license and machine marks, so the state
+// file can't be forged or copied to another machine/license.
+//
+// Deleting the state file resets it, so store it in a place hard to find, or see TrialV1 for multiple locations.
+type ClockGuardV1 struct {
+	Path      string
+	Tolerance time.Duration // backwards within tolerance is allowed, 0 is ClockV1DefaultTolerance
+	Step      time.Duration // now is recorded when it exceeds the last seen by step, 0 is ClockV1DefaultStep
+	key       []byte
+	mu        sync.Mutex // for read-modify-write of state file
+	seen      time.Time  // the highest time seen in memory, include unsaved
+}
+
+// NewClockGuardV1 marks with error are ignored
+func NewClockGuardV1(path string, l *LicenseV1, marks []*mark.Mark) (*ClockGuardV1, error) {
+	key, err := deriveV1Key(l.Sign, marks, clockV1Info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClockGuardV1{
+		Path: path,
+		key:  key,
+	}, nil
+}
+
+// deriveV1Key hkdf-sha256(sign, marks), info is for different usage
+func deriveV1Key(sign []byte, marks []*mark.Mark, info []byte) ([]byte, error) {
+	if len(sign) == 0 {
+		return nil, errors.New("missing sign of license")
+	}
+
+	ms := make([]*mark.Mark, 0, len(marks))
+	for _, m := range marks {
+		if m.E == "" {
+			ms = append(ms, m)
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].K < ms[j].K
+	})
+
+	salt := sha256.New()
+	for _, m := range ms {
+		json.NewEncoder(salt).Encode([]string{m.K, m.V})
+	}
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sign, salt.Sum(nil), info), key); err != nil {
+		return nil, errors.Wrap(err, "derive key")
+	}
+
+	return key, nil
+}
+
+func (g *ClockGuardV1) step() time.Duration {
+	if g.Step == 0 {
+		return ClockV1DefaultStep
+	}
+
+	return g.Step
+}
+
+func (g *ClockGuardV1) tolerance() time.Duration {
+	if g.Tolerance == 0 {
+		return ClockV1DefaultTolerance
+	}
+
+	return g.Tolerance
+}
+
+// LastSeen the highest time seen, zero when without state
+func (g *ClockGuardV1) LastSeen() (time.Time, error) {
+	data, err := os.ReadFile(g.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+
+		return time.Time{}, errors.Wrap(err, "read clock state")
+	}
+
+	s := &clockV1State{}
+	if err = json.Unmarshal(data, s); err != nil {
+		return time.Time{}, ErrClockStateTampered
+	}
+	if s.Version != ClockV1Version || !hmac.Equal(s.Mac, s.mac(g.key)) {
+		return time.Time{}, ErrClockStateTampered
+	}
+
+	return time.Unix(s.LastSeen, 0), nil
+}
+
+// Check now with the highest time seen(of state file and memory), and record now when it is higher by Step,
+// safe for concurrent use. Return the effective time: the highest time seen, for expiry check.
+// Failure of writing state is ErrClockStateUnsaved with the effective time, it isn't a rollback
+func (g *ClockGuardV1) Check(now time.Time) (time.Time, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	saved, err := g.LastSeen()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	last := saved
+	if g.seen.After(last) {
+		last = g.seen
+	}
+
+	if now.Before(last.Add(-g.tolerance())) {
+		return last, errors.Wrapf(ErrClockRollback, "now %s, last seen %s", now.Format(time.RFC3339), last.Format(time.RFC3339))
+	}
+
+	if !now.After(last) {
+		return last, nil
+	}
+	g.seen = now
+	if !saved.IsZero() && now.Before(saved.Add(g.step())) { // not recorded, rollback within step is tolerated
+		return now, nil
+	}
+
+	s := &clockV1State{
+		Version:  ClockV1Version,
+		LastSeen: now.Unix(),
+	}
+	s.Mac = s.mac(g.key)
+
+	data, _ := json.Marshal(s)
+	if err = atomicfile.WriteFile(g.Path, data, 0600); err != nil {
+		return now, errors.Wrap(ErrClockStateUnsaved, err.Error())
+	}
+
+	return now, nil
+}
+
+// effective Check, but ErrClockStateUnsaved is ignored, for evaluation
+func (g *ClockGuardV1) effective(now time.Time) (time.Time, error) {
+	now, err := g.Check(now)
+	if errors.Is(err, ErrClockStateUnsaved) {
+		return now, nil
+	}
+
+	return now, err
+}
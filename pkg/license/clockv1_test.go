@@ -0,0 +1,126 @@
+package license
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"superlicense/pkg/mark"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockGuardV1(t *testing.T) {
+	l := &LicenseV1{Sign: []byte("sign of license")}
+	marks := []*mark.Mark{{K: mark.MarkCodeMachineid, V: "machine-1"}}
+	fpath := filepath.Join(t.TempDir(), ".clock")
+
+	g, err := NewClockGuardV1(fpath, l, marks)
+	assert.Nil(t, err)
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	v, err := g.Check(now)
+	assert.Nil(t, err)
+	assert.Equal(t, now, v)
+
+	// within tolerance
+	v, err = g.Check(now.Add(-time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, now.Unix(), v.Unix())
+
+	// rollback
+	_, err = g.Check(now.Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrClockRollback)
+
+	// forward within step isn't recorded
+	v, err = g.Check(now.Add(30 * time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(30*time.Second), v)
+	last, _ := g.LastSeen()
+	assert.Equal(t, now.Unix(), last.Unix())
+
+	// forward
+	_, err = g.Check(now.Add(time.Hour))
+	assert.Nil(t, err)
+	last, _ = g.LastSeen()
+	assert.Equal(t, now.Add(time.Hour).Unix(), last.Unix())
+
+	// key of other machine
+	g2, _ := NewClockGuardV1(fpath, l, []*mark.Mark{{K: mark.MarkCodeMachineid, V: "machine-2"}})
+	_, err = g2.Check(now)
+	assert.ErrorIs(t, err, ErrClockStateTampered)
+
+	// tampered
+	data, _ := os.ReadFile(fpath)
+	data[len(data)/2] ^= 1
+	assert.Nil(t, os.WriteFile(fpath, data, 0600))
+	_, err = g.Check(now)
+	assert.ErrorIs(t, err, ErrClockStateTampered)
+}
+
+func TestClockGuardV1Concurrent(t *testing.T) {
+	g, err := NewClockGuardV1(filepath.Join(t.TempDir(), ".clock"), &LicenseV1{Sign: []byte("sign")}, nil)
+	assert.Nil(t, err)
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := g.Check(now.Add(time.Duration(i) * 30 * time.Second)) // within tolerance
+			assert.Nil(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	last, err := g.LastSeen()
+	assert.Nil(t, err)
+	assert.False(t, last.Before(now))
+	assert.False(t, last.After(now.Add(15*30*time.Second)))
+}
+
+func TestClockGuardV1Unsaved(t *testing.T) {
+	// dir of state doesn't exist, can't be written
+	g, err := NewClockGuardV1(filepath.Join(t.TempDir(), "missing", ".clock"), &LicenseV1{Sign: []byte("sign")}, nil)
+	assert.Nil(t, err)
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	v, err := g.Check(now)
+	assert.ErrorIs(t, err, ErrClockStateUnsaved)
+	assert.Equal(t, now, v)
+
+	// rollback is still detected in memory
+	_, err = g.Check(now.Add(-time.Hour))
+	assert.ErrorIs(t, err, ErrClockRollback)
+
+	// valid license isn't disabled
+	exp := []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: now.AddDate(0, 0, 30).Unix()}}
+	env := &AuthV1Env{Now: now.Add(time.Hour), Clock: g}
+	assert.Nil(t, EvalAuthV1s([]*AuthV1Check{WithExpiredAt()}, exp, env))
+	assert.Equal(t, ExpiryValid, ExpiryOfAuthV1s(exp, env).State)
+}
+
+func TestEvalAuthV1sClock(t *testing.T) {
+	exp := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	auths := []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: exp.Unix()}}
+	checks := []*AuthV1Check{WithExpiredAt()}
+
+	g, err := NewClockGuardV1(filepath.Join(t.TempDir(), ".clock"), &LicenseV1{Sign: []byte("sign")}, nil)
+	assert.Nil(t, err)
+
+	// used after expiry, then set the clock back
+	assert.NotNil(t, EvalAuthV1s(checks, auths, &AuthV1Env{Now: exp.Add(time.Hour), Clock: g}))
+
+	err = EvalAuthV1s(checks, auths, &AuthV1Env{Now: exp.AddDate(0, 0, -1), Clock: g})
+	assert.ErrorIs(t, err, ErrClockRollback)
+
+	s := ExpiryOfAuthV1s(auths, &AuthV1Env{Now: exp.AddDate(0, 0, -1), Clock: g})
+	assert.Equal(t, ExpiryExpired, s.State)
+	assert.ErrorIs(t, s.Err, ErrClockRollback)
+}
@@ -0,0 +1,238 @@
+package license
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AuthV1CodeEdition = "edition"
+
+	AuthV1ReasonFixed = "fixed by edition"
+)
+
+// EditionV1 版本(Community, Pro, Enterprise), 是一组预设的auths:
+// - Fixed: 固定值, 签发时不能修改
+// - Open: 默认值, 签发时可以修改
+// - Inherit: 继承另一个版本, 同code时覆盖被继承的值
+type EditionV1 struct {
+	Name    string            `json:"name" yaml:"name"`
+	Inherit string            `json:"inherit,omitempty" yaml:"inherit,omitempty"`
+	Fixed   map[string]string `json:"fixed,omitempty" yaml:"fixed,omitempty"`
+	Open    map[string]string `json:"open,omitempty" yaml:"open,omitempty"`
+}
+
+// LicenserV1Editions optional for LicenserV1
+type LicenserV1Editions interface {
+	Editions() []*EditionV1
+}
+
+// validEditionV1s check inherit and codes of editions
+func validEditionV1s(checks []*AuthV1Check, es []*EditionV1) error {
+	cm := make(map[string]bool, len(checks))
+	for _, c := range checks {
+		cm[c.Code] = true
+	}
+
+	em := make(map[string]*EditionV1, len(es))
+	for _, e := range es {
+		if e.Name == "" {
+			return errors.New("missing edition name")
+		}
+		if em[e.Name] != nil {
+			return errors.Errorf("double edition: %s", e.Name)
+		}
+
+		em[e.Name] = e
+	}
+
+	for _, e := range es {
+		if _, err := resolveEditionV1(em, e.Name); err != nil {
+			return err
+		}
+
+		for _, m := range []map[string]string{e.Fixed, e.Open} {
+			for code := range m {
+				if !cm[code] {
+					return errors.Errorf("unsupported Auth of edition(%s): %s", e.Name, code)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveEditionV1 merge inherit chain, return code -> preset
+func resolveEditionV1(em map[string]*EditionV1, name string) (map[string]*editionV1Preset, error) {
+	chain := make([]*EditionV1, 0)
+	seen := make(map[string]bool)
+
+	for n := name; n != ""; {
+		e := em[n]
+		if e == nil {
+			return nil, errors.Errorf("unknown edition: %s", n)
+		}
+		if seen[n] {
+			return nil, errors.Errorf("cyclic inherit of edition: %s", name)
+		}
+
+		seen[n] = true
+		chain = append(chain, e)
+		n = e.Inherit
+	}
+
+	ps := make(map[string]*editionV1Preset)
+	for i := len(chain) - 1; i >= 0; i-- { // from base
+		for code, v := range chain[i].Open {
+			ps[code] = &editionV1Preset{Content: v}
+		}
+		for code, v := range chain[i].Fixed {
+			ps[code] = &editionV1Preset{Content: v, Fixed: true}
+		}
+	}
+
+	return ps, nil
+}
+
+type editionV1Preset struct {
+	Content string
+	Fixed   bool
+}
+
+// EditionV1Presets fixed and open auths of edition with inherit, code -> content
+func EditionV1Presets(l LicenserV1, edition string) (fixed, open map[string]string, err error) {
+	em := make(map[string]*EditionV1)
+	if le, ok := l.(LicenserV1Editions); ok {
+		for _, e := range le.Editions() {
+			em[e.Name] = e
+		}
+	}
+
+	ps, err := resolveEditionV1(em, edition)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fixed, open = make(map[string]string), make(map[string]string)
+	for code, p := range ps {
+		if p.Fixed {
+			fixed[code] = p.Content
+		} else {
+			open[code] = p.Content
+		}
+	}
+
+	return fixed, open, nil
+}
+
+// ExpandEditionV1 expand edition to auths, the auths of req override Open, but not Fixed.
+// With AuthV1Errors, the auths without problem are also returned, for reporting all problems
+func ExpandEditionV1(l LicenserV1, edition string, auths []*AuthV1) ([]*AuthV1, error) {
+	le, ok := l.(LicenserV1Editions)
+	if !ok {
+		return nil, errors.Errorf("licensev1 without editions: %s", l.Name())
+	}
+
+	em := make(map[string]*EditionV1)
+	for _, e := range le.Editions() {
+		em[e.Name] = e
+	}
+
+	ps, err := resolveEditionV1(em, edition)
+	if err != nil {
+		return nil, err
+	}
+
+	cm := make(map[string]*AuthV1Check)
+	for _, c := range l.Checks() {
+		cm[c.Code] = c
+	}
+
+	var errs AuthV1Errors
+	given := make(map[string]bool, len(auths))
+	nauths := make([]*AuthV1, 0, len(auths)+len(ps))
+
+	for _, a := range auths {
+		if p := ps[a.Code]; p != nil && p.Fixed && !sameAsEditionV1Preset(cm[a.Code], a, p.Content) {
+			errs = append(errs, newAuthV1Error(cm[a.Code], a.Code, AuthV1ReasonFixed, errors.Errorf("need %s", p.Content)))
+
+			continue
+		}
+
+		given[a.Code] = true
+		nauths = append(nauths, a)
+	}
+
+	codes := make([]string, 0, len(ps))
+	for code := range ps {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if !given[code] {
+			a := &AuthV1{Code: code, Content: ps[code].Content}
+
+			// preset of expired auth is the expiration
+			if c := cm[code]; c != nil && c.RequredExpired && !c.RequredContent {
+				ts, err := ParseAuthV1Date(a.Content)
+				if err != nil {
+					errs = append(errs, newAuthV1Error(c, code, AuthV1ReasonInvalid, err))
+
+					continue
+				}
+
+				a.Content, a.ExpiredAt = "", ts
+			}
+
+			nauths = append(nauths, a)
+		}
+	}
+
+	return nauths, errs.err()
+}
+
+// sameAsEditionV1Preset compare auth with the preset by type of check: ExpiredAt of expired auth,
+// normalized Content of others, items of list are compared regardless of order
+func sameAsEditionV1Preset(c *AuthV1Check, a *AuthV1, preset string) bool {
+	if c == nil {
+		return a.Content == preset
+	}
+
+	if c.RequredExpired && !c.RequredContent {
+		ts, err := ParseAuthV1Date(preset)
+
+		return err == nil && a.ExpiredAt == ts
+	}
+
+	want, err := normalizeAuthV1Content(c.Type, preset)
+	if err != nil {
+		return a.Content == preset
+	}
+	got, err := normalizeAuthV1Content(c.Type, a.Content)
+	if err != nil {
+		return false
+	}
+
+	if c.Type == AuthV1TypeList {
+		ws, gs := splitAuthV1List(want), splitAuthV1List(got)
+		sort.Strings(ws)
+		sort.Strings(gs)
+
+		return slices.Equal(ws, gs)
+	}
+
+	return got == want
+}
+
+// Edition edition of license issued as, empty is without edition
+func (l *LicenseV1) Edition() string {
+	if a := l.Auth(AuthV1CodeEdition); a != nil {
+		return a.Content
+	}
+
+	return ""
+}
@@ -0,0 +1,102 @@
+package license
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const editionV1TestYaml = `
+name: edition_v1_test
+auths:
+  - code: expired_at
+    required: true
+    required_expired: true
+    validator: {type: date, after: now}
+  - code: max_users
+    required: true
+    required_content: true
+    validator: {type: int, min: 1}
+  - code: features
+    required_content: true
+    validator: {type: list, values: [report, export, sso]}
+editions:
+  - name: community
+    fixed: {max_users: "10", features: report}
+  - name: pro
+    inherit: community
+    fixed: {features: "report,export"}
+    open: {max_users: "100"}
+  - name: enterprise
+    inherit: pro
+    fixed: {features: "report,export,sso"}
+  - name: trial
+    fixed: {expired_at: "2099-01-01", features: report}
+rules:
+  - name: enterprise_users
+    when: [{code: edition, op: eq, value: enterprise}]
+    then: [{code: max_users, op: ge, value: "100"}]
+`
+
+type EditionV1Test struct {
+	Name     string
+	Edition  string
+	Auths    []*AuthV1
+	IsErr    bool
+	MaxUsers int64
+	Features string
+}
+
+func TestEditionV1(t *testing.T) {
+	ds, err := ParseProductV1s([]byte(editionV1TestYaml))
+	assert.Nil(t, err)
+
+	l, err := NewProductV1(ds[0])
+	assert.Nil(t, err)
+	assert.Nil(t, TryRegisterLicenseV1(l))
+
+	exp := &AuthV1{Code: AuthV1CodeExpiredAt, ExpiredAt: time.Now().Add(time.Hour).Unix()}
+	trialExp, err := ParseAuthV1Date("2099-01-01")
+	assert.Nil(t, err)
+
+	cases := []*EditionV1Test{
+		{Name: "community", Edition: "community", Auths: []*AuthV1{exp}, MaxUsers: 10, Features: "report"},
+		{Name: "pro default", Edition: "pro", Auths: []*AuthV1{exp}, MaxUsers: 100, Features: "report,export"},
+		{Name: "pro open", Edition: "pro", Auths: []*AuthV1{exp, {Code: "max_users", Content: "200"}}, MaxUsers: 200, Features: "report,export"},
+		{Name: "enterprise", Edition: "enterprise", Auths: []*AuthV1{exp}, MaxUsers: 100, Features: "report,export,sso"},
+		{Name: "community fixed", Edition: "community", Auths: []*AuthV1{exp, {Code: "max_users", Content: "20"}}, IsErr: true},
+		{Name: "enterprise rule", Edition: "enterprise", Auths: []*AuthV1{exp, {Code: "max_users", Content: "50"}}, IsErr: true},
+		{Name: "unknown", Edition: "ultimate", Auths: []*AuthV1{exp}, IsErr: true},
+		{Name: "fixed list in other order", Edition: "pro", Auths: []*AuthV1{exp, {Code: "features", Content: "export, report"}}, MaxUsers: 100, Features: "export,report"},
+		{Name: "fixed list changed", Edition: "pro", Auths: []*AuthV1{exp, {Code: "features", Content: "report,sso"}}, IsErr: true},
+		{Name: "fixed expiration", Edition: "trial", Auths: []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: trialExp}, {Code: "max_users", Content: "5"}}, MaxUsers: 5, Features: "report"},
+		{Name: "fixed expiration changed", Edition: "trial", Auths: []*AuthV1{exp, {Code: "max_users", Content: "5"}}, IsErr: true},
+	}
+
+	for _, c := range cases {
+		auths, err := ValidLicenseV1(&CreateLicenseV1Req{Name: l.Name(), Edition: c.Edition, Auths: c.Auths})
+		assert.Equal(t, c.IsErr, err != nil, c.Name)
+		if err != nil {
+			continue
+		}
+
+		lic := &LicenseV1{Auths: auths}
+		assert.Equal(t, c.Edition, lic.Edition(), c.Name)
+
+		n, err := lic.Int("max_users")
+		assert.Nil(t, err, c.Name)
+		assert.Equal(t, c.MaxUsers, n, c.Name)
+
+		assert.Equal(t, c.Features, lic.Auth("features").Content, c.Name)
+	}
+}
+
+func TestEditionV1Invalid(t *testing.T) {
+	checks := []*AuthV1Check{WithMaxUsers(1, 100)}
+
+	assert.NotNil(t, validEditionV1s(checks, []*EditionV1{{Name: "a", Inherit: "b"}, {Name: "b", Inherit: "a"}}))
+	assert.NotNil(t, validEditionV1s(checks, []*EditionV1{{Name: "a", Inherit: "c"}}))
+	assert.NotNil(t, validEditionV1s(checks, []*EditionV1{{Name: "a", Fixed: map[string]string{"x": "1"}}}))
+	assert.Nil(t, validEditionV1s(checks, []*EditionV1{{Name: "a", Fixed: map[string]string{AuthV1CodeMaxUsers: "1"}}, {Name: "b", Inherit: "a"}}))
+}
@@ -0,0 +1,179 @@
+package license
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Entitlements read-only view of a verified license indexed by code, safe for concurrent use.
+// An auth with ExpiredAt is unavailable after ExpiredAt + grace, and all auths are unavailable after the license expired
+type Entitlements struct {
+	l         *LicenseV1
+	auths     map[string]*AuthV1
+	env       AuthV1Env // Now is usually zero for the current time
+	expiredAt int64     // of license
+}
+
+// NewEntitlements env is optional, for grace, ticket or fixed Now. env.Clock is skipped for hot path,
+// it is checked by EvalAuthV1s or Watch
+func NewEntitlements(l *LicenseV1, env *AuthV1Env) *Entitlements {
+	e := &Entitlements{
+		l:     l,
+		auths: make(map[string]*AuthV1, len(l.Auths)),
+	}
+	if env != nil {
+		e.env = *env
+	}
+	e.env.Clock = nil
+	e.env.Grace = daysOf(l.Auths, AuthV1CodeGraceDays, e.env.Grace)
+
+	for _, a := range l.Auths {
+		if _, isExist := e.auths[a.Code]; isExist { // same as LicenseV1.Auth, the first one
+			continue
+		}
+
+		e.auths[a.Code] = a
+		if a.Code == AuthV1CodeExpiredAt {
+			e.expiredAt = a.ExpiredAt
+		}
+	}
+
+	return e
+}
+
+func (l *LicenseV1) Entitlements(env *AuthV1Env) *Entitlements {
+	return NewEntitlements(l, env)
+}
+
+func (e *Entitlements) License() *LicenseV1 {
+	return e.l
+}
+
+// Codes sorted codes of all auths, include expired
+func (e *Entitlements) Codes() []string {
+	cs := make([]string, 0, len(e.auths))
+	for k := range e.auths {
+		cs = append(cs, k)
+	}
+	sort.Strings(cs)
+
+	return cs
+}
+
+// Check auth is present and not expired, error is ErrAuthV1NotFound or ErrAuthV1Expired
+func (e *Entitlements) Check(code string) (*AuthV1, error) {
+	a := e.auths[code]
+	if a == nil {
+		return nil, errors.Wrap(ErrAuthV1NotFound, code)
+	}
+
+	now := e.env.now()
+	if e.env.Ticket != nil && now.Unix() > e.env.Ticket.NotAfter {
+		return nil, errors.Wrap(ErrAuthV1Expired, ErrTicketExpired.Error())
+	}
+	if e.expired(e.expiredAt, now) {
+		return nil, errors.Wrap(ErrAuthV1Expired, "license")
+	}
+	if e.expired(a.ExpiredAt, now) {
+		return nil, errors.Wrap(ErrAuthV1Expired, code)
+	}
+
+	return a, nil
+}
+
+func (e *Entitlements) expired(expiredAt int64, now time.Time) bool {
+	return expiredAt != 0 && !now.Before(time.Unix(expiredAt, 0).Add(e.env.Grace))
+}
+
+// Has auth is present and not expired
+func (e *Entitlements) Has(code string) bool {
+	_, err := e.Check(code)
+
+	return err == nil
+}
+
+// Get auth when Has
+func (e *Entitlements) Get(code string) (*AuthV1, bool) {
+	a, err := e.Check(code)
+
+	return a, err == nil
+}
+
+// Lookup auth regardless of expiry, e.g. for showing expired features
+func (e *Entitlements) Lookup(code string) (*AuthV1, bool) {
+	a, isExist := e.auths[code]
+
+	return a, isExist
+}
+
+// Expiry of auth, zero when the auth hasn't ExpiredAt or not found
+func (e *Entitlements) Expiry(code string) time.Time {
+	if a := e.auths[code]; a != nil && a.ExpiredAt != 0 {
+		return time.Unix(a.ExpiredAt, 0)
+	}
+
+	return time.Time{}
+}
+
+// EntitlementsHolder holds the current Entitlements for hot path, safe for concurrent use,
+// e.g. updated by OnWatchEvent of Watch, and queried by handlers
+type EntitlementsHolder struct {
+	p atomic.Pointer[Entitlements]
+	// Env of Entitlements created by OnWatchEvent
+	Env *AuthV1Env
+}
+
+// DefaultEntitlements global holder, optional
+var DefaultEntitlements = &EntitlementsHolder{}
+
+func (h *EntitlementsHolder) Store(e *Entitlements) {
+	h.p.Store(e)
+}
+
+// Load nil when without license, e.g. source of httpgate
+func (h *EntitlementsHolder) Load() *Entitlements {
+	return h.p.Load()
+}
+
+// License nil when without license
+func (h *EntitlementsHolder) License() *LicenseV1 {
+	if e := h.Load(); e != nil {
+		return e.License()
+	}
+
+	return nil
+}
+
+// Has false when without license
+func (h *EntitlementsHolder) Has(code string) bool {
+	e := h.Load()
+
+	return e != nil && e.Has(code)
+}
+
+func (h *EntitlementsHolder) Get(code string) (*AuthV1, bool) {
+	if e := h.Load(); e != nil {
+		return e.Get(code)
+	}
+
+	return nil, false
+}
+
+// OnWatchEvent for WatchOptions.OnEvent, stores the license that passes verification, and clears it
+// on WatchInvalid or WatchExpired with Err(e.g. clock rollback) until WatchChanged/WatchRecovered.
+// The license is kept on WatchExpired by time, expiry is checked by Entitlements
+func (h *EntitlementsHolder) OnWatchEvent(e *WatchEvent) {
+	switch e.Type {
+	case WatchLoaded, WatchChanged, WatchRecovered:
+		h.Store(NewEntitlements(e.License, h.Env))
+	case WatchInvalid:
+		h.Store(nil)
+	case WatchExpired:
+		if e.Err != nil {
+			h.Store(nil)
+		}
+	}
+}
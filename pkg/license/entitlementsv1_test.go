@@ -0,0 +1,171 @@
+package license
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type EntitlementsTest struct {
+	Code  string
+	Has   bool
+	IsErr error
+}
+
+func TestEntitlements(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := &LicenseV1{Auths: []*AuthV1{
+		{Code: AuthV1CodeExpiredAt, ExpiredAt: now.Add(10 * 24 * time.Hour).Unix()},
+		{Code: AuthV1CodeModel, Content: "X100"},
+		{Code: "sso", Content: "t", ExpiredAt: now.Add(-time.Hour).Unix()},
+		{Code: "audit", Content: "t", ExpiredAt: now.Add(time.Hour).Unix()},
+		{Code: AuthV1CodeGraceDays, Content: "0"},
+	}}
+
+	e := l.Entitlements(&AuthV1Env{Now: now})
+	assert.Equal(t, []string{"audit", AuthV1CodeExpiredAt, AuthV1CodeGraceDays, AuthV1CodeModel, "sso"}, e.Codes())
+
+	cases := []*EntitlementsTest{
+		{Code: AuthV1CodeModel, Has: true},
+		{Code: "audit", Has: true},
+		{Code: "sso", IsErr: ErrAuthV1Expired},
+		{Code: "cluster", IsErr: ErrAuthV1NotFound},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.Has, e.Has(c.Code), c.Code)
+
+		_, err := e.Check(c.Code)
+		if c.IsErr != nil {
+			assert.ErrorIs(t, err, c.IsErr, c.Code)
+		} else {
+			assert.Nil(t, err, c.Code)
+		}
+	}
+
+	a, ok := e.Get(AuthV1CodeModel)
+	assert.True(t, ok)
+	assert.Equal(t, "X100", a.Content)
+
+	_, ok = e.Get("sso")
+	assert.False(t, ok)
+	_, ok = e.Lookup("sso")
+	assert.True(t, ok)
+	assert.Equal(t, now.Add(-time.Hour).Unix(), e.Expiry("sso").Unix())
+	assert.True(t, e.Expiry(AuthV1CodeModel).IsZero())
+
+	// grace of license
+	l.Auths[4].Content = "7"
+	e = l.Entitlements(&AuthV1Env{Now: now})
+	assert.True(t, e.Has("sso"))
+
+	// license expired
+	e = l.Entitlements(&AuthV1Env{Now: now.Add(30 * 24 * time.Hour)})
+	_, err := e.Check(AuthV1CodeModel)
+	assert.ErrorIs(t, err, ErrAuthV1Expired)
+
+	// the same as CheckAuth
+	_, err = l.CheckAuth(AuthV1CodeModel, &AuthV1Env{Now: now.Add(30 * 24 * time.Hour)})
+	assert.ErrorIs(t, err, ErrAuthV1Expired)
+}
+
+func TestEntitlementsHolder(t *testing.T) {
+	h := &EntitlementsHolder{}
+	assert.False(t, h.Has(AuthV1CodeModel))
+	assert.Nil(t, h.License())
+
+	l := &LicenseV1{Auths: []*AuthV1{{Code: AuthV1CodeModel, Content: "X100"}}}
+	h.OnWatchEvent(&WatchEvent{Type: WatchInvalid})
+	assert.Nil(t, h.Load())
+
+	h.OnWatchEvent(&WatchEvent{Type: WatchLoaded, License: l})
+	assert.Equal(t, l, h.License())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				assert.True(t, h.Has(AuthV1CodeModel))
+				h.Store(NewEntitlements(l, nil))
+			}
+		}()
+	}
+	wg.Wait()
+
+	a, ok := h.Get(AuthV1CodeModel)
+	assert.True(t, ok)
+	assert.Equal(t, "X100", a.Content)
+
+	h.OnWatchEvent(&WatchEvent{Type: WatchInvalid, License: l})
+	assert.False(t, h.Has(AuthV1CodeModel))
+	h.OnWatchEvent(&WatchEvent{Type: WatchRecovered, License: l})
+	assert.True(t, h.Has(AuthV1CodeModel))
+
+	// clock rollback
+	h.OnWatchEvent(&WatchEvent{Type: WatchExpired, License: l, Err: ErrClockRollback})
+	assert.Nil(t, h.License())
+}
+
+func TestEntitlementsHolderWatch(t *testing.T) {
+	l := registerWatchV1Demo(t)
+
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	fpath := filepath.Join(t.TempDir(), "license.dat")
+	auths := []*AuthV1{{Code: AuthV1CodeMACs, Content: "00:16:3e:00:00:01"}}
+	assert.Nil(t, BuildLicenseV1File(fpath, auths, priv, nil, LicenseV1FlagRaw))
+
+	var mac atomic.Value
+	mac.Store("00:16:3e:00:00:02")
+
+	h := &EntitlementsHolder{}
+	events := make(chan *WatchEvent, 8)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := Watch(ctx, fpath, &WatchKeys{Pub: pub}, &WatchOptions{
+		Interval: 10 * time.Millisecond,
+		Verify:   10 * time.Millisecond,
+		Product:  l.Name(),
+		Env:      func() *AuthV1Env { return &AuthV1Env{MACs: []string{mac.Load().(string)}} },
+		OnEvent: func(e *WatchEvent) {
+			h.OnWatchEvent(e)
+			events <- e
+		},
+	})
+	assert.Nil(t, err)
+
+	// license bound to other mac fails evaluation, isn't loaded
+	e := nextWatchEvent(t, events)
+	assert.Equal(t, WatchInvalid, e.Type)
+	assert.Nil(t, e.License)
+	assert.False(t, h.Has(AuthV1CodeMACs))
+	assert.Nil(t, h.License())
+
+	// the license is loaded by a new file, when the mac matches
+	mac.Store("00:16:3e:00:00:01")
+	assert.Nil(t, BuildLicenseV1File(fpath, append(auths, &AuthV1{Code: AuthV1CodeExpiredAt, ExpiredAt: time.Now().AddDate(1, 0, 0).Unix()}), priv, nil, LicenseV1FlagRaw))
+	e = nextWatchEvent(t, events)
+	assert.Equal(t, WatchLoaded, e.Type)
+	assert.True(t, h.Has(AuthV1CodeMACs))
+
+	// the license is cleared when it fails evaluation mid-run
+	mac.Store("00:16:3e:00:00:02")
+	e = nextWatchEvent(t, events)
+	assert.Equal(t, WatchInvalid, e.Type)
+	assert.False(t, h.Has(AuthV1CodeMACs))
+
+	mac.Store("00:16:3e:00:00:01")
+	e = nextWatchEvent(t, events)
+	assert.Equal(t, WatchRecovered, e.Type)
+	assert.True(t, h.Has(AuthV1CodeMACs))
+}
@@ -0,0 +1,189 @@
+package license
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AuthV1CodeGraceDays = "grace_days"
+	AuthV1CodeWarnDays  = "warn_days"
+)
+
+type ExpiryState int
+
+const (
+	ExpiryValid        ExpiryState = iota // not expired, or without expiry
+	ExpiryExpiringSoon                    // in warn window before expiry
+	ExpiryInGrace                         // expired, but in grace period, should nag before disable
+	ExpiryExpired                         // expired and out of grace period
+)
+
+func (s ExpiryState) String() string {
+	switch s {
+	case ExpiryValid:
+		return "Valid"
+	case ExpiryExpiringSoon:
+		return "ExpiringSoon"
+	case ExpiryInGrace:
+		return "InGrace"
+	case ExpiryExpired:
+		return "Expired"
+	default:
+		return "Unknown"
+	}
+}
+
+// ExpiryStatus expiry of license by expired_at
+type ExpiryStatus struct {
+	State     ExpiryState
+	ExpiredAt int64         // 0 is no expire
+	Days      int           // days to expiry, only for ExpiryExpiringSoon, round up
+	Remaining time.Duration // remaining of grace, only for ExpiryInGrace
+	Grace     time.Duration
+	Warn      time.Duration
+	Err       error // clock rollback or state tampered of AuthV1Env.Clock, or expired AuthV1Env.Ticket, the license is flagged as Expired
+}
+
+func (s *ExpiryStatus) String() string {
+	if s.Err != nil {
+		return s.State.String() + "(" + s.Err.Error() + ")"
+	}
+
+	switch s.State {
+	case ExpiryExpiringSoon:
+		return fmt.Sprintf("ExpiringSoon(%d days)", s.Days)
+	case ExpiryInGrace:
+		return fmt.Sprintf("InGrace(%s)", s.Remaining)
+	default:
+		return s.State.String()
+	}
+}
+
+// Usable Valid, ExpiringSoon or InGrace
+func (s *ExpiryStatus) Usable() bool {
+	return s.State != ExpiryExpired
+}
+
+// WithGraceDays grace period after expiry in [0, max] days, overrides AuthV1Env.Grace
+func WithGraceDays(max int64) *AuthV1Check {
+	return withDaysCheck(AuthV1CodeGraceDays, "宽限天数", "Grace Days", 0, max)
+}
+
+// WithWarnDays warn window before expiry in [0, max] days, overrides AuthV1Env.Warn
+func WithWarnDays(max int64) *AuthV1Check {
+	return withDaysCheck(AuthV1CodeWarnDays, "提醒天数", "Warn Days", 0, max)
+}
+
+// withDaysCheck days in [min, max], read by daysOf, without Eval
+func withDaysCheck(code, name, enName string, min, max int64) *AuthV1Check {
+	return &AuthV1Check{
+		Code: code,
+		Type: AuthV1TypeInt,
+		Name: name,
+		I18n: map[string]*AuthV1Text{LangEn: {Name: enName}},
+		Check: func(content string, expiredAt int64) error {
+			n, err := strconv.ParseInt(content, 10, 64)
+			if err != nil {
+				return errors.New("need integer")
+			}
+			if n < min || n > max {
+				return errors.Errorf("need in [%d, %d]", min, max)
+			}
+
+			return nil
+		},
+		RequredContent: true,
+		Example:        strconv.FormatInt(max, 10),
+		Tip:            fmt.Sprintf("[%d, %d]", min, max),
+		Validator:      &AuthV1Validator{Type: AuthV1ValidatorInt, Min: &min, Max: &max},
+	}
+}
+
+// daysOf duration of days auth, def when without the auth
+func daysOf(auths []*AuthV1, code string, def time.Duration) time.Duration {
+	for _, a := range auths {
+		if a.Code == code {
+			if n, err := strconv.ParseInt(a.Content, 10, 64); err == nil && n >= 0 {
+				return time.Duration(n) * 24 * time.Hour
+			}
+		}
+	}
+
+	return def
+}
+
+// ExpiryOfAuthV1s expiry status by expired_at, grace_days and warn_days, env.Grace/env.Warn are defaults
+func ExpiryOfAuthV1s(auths []*AuthV1, env *AuthV1Env) *ExpiryStatus {
+	if env == nil {
+		env = &AuthV1Env{}
+	}
+
+	s := &ExpiryStatus{
+		State: ExpiryValid,
+		Grace: daysOf(auths, AuthV1CodeGraceDays, env.Grace),
+		Warn:  daysOf(auths, AuthV1CodeWarnDays, env.Warn),
+	}
+
+	for _, a := range auths {
+		if a.Code == AuthV1CodeExpiredAt {
+			s.ExpiredAt = a.ExpiredAt
+		}
+	}
+	if s.ExpiredAt == 0 {
+		return s
+	}
+
+	now := env.now()
+	if env.Clock != nil {
+		var err error
+		if now, err = env.Clock.effective(now); err != nil {
+			s.State, s.Err = ExpiryExpired, err
+
+			return s
+		}
+	}
+	if env.Ticket != nil && now.Unix() > env.Ticket.NotAfter {
+		s.State, s.Err = ExpiryExpired, ErrTicketExpired
+
+		return s
+	}
+
+	exp := time.Unix(s.ExpiredAt, 0)
+
+	switch {
+	case now.Before(exp.Add(-s.Warn)):
+	case now.Before(exp):
+		s.State = ExpiryExpiringSoon
+		s.Days = int((exp.Sub(now) + 24*time.Hour - 1) / (24 * time.Hour))
+	case now.Before(exp.Add(s.Grace)):
+		s.State = ExpiryInGrace
+		s.Remaining = exp.Add(s.Grace).Sub(now).Truncate(time.Second)
+	default:
+		s.State = ExpiryExpired
+	}
+
+	return s
+}
+
+func (l *LicenseV1) Expiry(env *AuthV1Env) *ExpiryStatus {
+	return ExpiryOfAuthV1s(l.Auths, env)
+}
+
+// CheckAuth auth is present, and neither it nor the license is expired(with grace), for gating features at runtime.
+// For many queries, see Entitlements
+func (l *LicenseV1) CheckAuth(code string, env *AuthV1Env) (*AuthV1, error) {
+	return NewEntitlements(l, env).Check(code)
+}
+
+// errExpired for EvalAuthV1s, auth is expired after grace
+func errExpired(a *AuthV1, env *AuthV1Env) error {
+	if a.ExpiredAt != 0 && !env.now().Before(time.Unix(a.ExpiredAt, 0).Add(env.Grace)) {
+		return errors.New("expired")
+	}
+
+	return nil
+}
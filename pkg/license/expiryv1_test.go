@@ -0,0 +1,57 @@
+package license
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ExpiryV1Test struct {
+	Name      string
+	Now       time.Time
+	State     ExpiryState
+	Days      int
+	Remaining time.Duration
+}
+
+func TestExpiryOfAuthV1s(t *testing.T) {
+	exp := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	auths := []*AuthV1{
+		{Code: AuthV1CodeExpiredAt, ExpiredAt: exp.Unix()},
+		{Code: AuthV1CodeGraceDays, Content: "7"},
+		{Code: AuthV1CodeWarnDays, Content: "30"},
+	}
+
+	cases := []*ExpiryV1Test{
+		{Name: "valid", Now: exp.AddDate(0, 0, -31), State: ExpiryValid},
+		{Name: "expiring soon", Now: exp.AddDate(0, 0, -30), State: ExpiryExpiringSoon, Days: 30},
+		{Name: "expiring in hours", Now: exp.Add(-time.Hour), State: ExpiryExpiringSoon, Days: 1},
+		{Name: "in grace", Now: exp, State: ExpiryInGrace, Remaining: 7 * 24 * time.Hour},
+		{Name: "in grace last day", Now: exp.AddDate(0, 0, 6), State: ExpiryInGrace, Remaining: 24 * time.Hour},
+		{Name: "expired", Now: exp.AddDate(0, 0, 7), State: ExpiryExpired},
+	}
+
+	for _, c := range cases {
+		s := ExpiryOfAuthV1s(auths, &AuthV1Env{Now: c.Now})
+		assert.Equal(t, c.State, s.State, c.Name)
+		assert.Equal(t, c.Days, s.Days, c.Name)
+		assert.Equal(t, c.Remaining, s.Remaining, c.Name)
+		assert.Equal(t, c.State != ExpiryExpired, s.Usable(), c.Name)
+
+		// runtime evaluation allows grace period
+		err := EvalAuthV1s([]*AuthV1Check{WithExpiredAt(), WithGraceDays(30), WithWarnDays(90)}, auths, &AuthV1Env{Now: c.Now})
+		assert.Equal(t, c.State == ExpiryExpired, err != nil, c.Name)
+	}
+
+	assert.Equal(t, "ExpiringSoon(30 days)", ExpiryOfAuthV1s(auths, &AuthV1Env{Now: exp.AddDate(0, 0, -30)}).String())
+	assert.Equal(t, "InGrace(168h0m0s)", ExpiryOfAuthV1s(auths, &AuthV1Env{Now: exp}).String())
+
+	// default of env without grace_days/warn_days
+	s := ExpiryOfAuthV1s(auths[:1], &AuthV1Env{Now: exp.Add(time.Hour), Grace: 2 * time.Hour})
+	assert.Equal(t, ExpiryInGrace, s.State)
+	assert.Equal(t, time.Hour, s.Remaining)
+
+	// without expiry
+	assert.Equal(t, ExpiryValid, ExpiryOfAuthV1s(nil, nil).State)
+}
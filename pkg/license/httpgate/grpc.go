@@ -0,0 +1,32 @@
+package httpgate
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor methods are full method names(e.g. /pkg.Service/Method) or their prefixes(e.g. /pkg.Service/),
+// the longest matched one is used, denied request is codes.PermissionDenied with reason
+func (g *Gate) UnaryServerInterceptor(methods map[string][]*Requirement) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := g.Check(match(methods, info.FullMethod)...); err != nil {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor same as UnaryServerInterceptor, checked once when the stream is opened
+func (g *Gate) StreamServerInterceptor(methods map[string][]*Requirement) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := g.Check(match(methods, info.FullMethod)...); err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,167 @@
+// Package httpgate gates http routes and grpc methods on auths of a shared verified license
+package httpgate
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"superlicense/pkg/license"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ReasonNoLicense  = "no_license"  // license isn't loaded
+	ReasonMissing    = "missing"     // auth isn't in license
+	ReasonExpired    = "expired"     // auth or license is expired after grace
+	ReasonNotAllowed = "not_allowed" // content of auth doesn't allow the values
+)
+
+// Requirement auth Code is required, and Values are optional:
+// - list auth, e.g. features: all Values must be in the list
+// - others, e.g. model: content must be one of Values
+type Requirement struct {
+	Code   string
+	Values []string
+}
+
+func Require(code string, values ...string) *Requirement {
+	return &Requirement{Code: code, Values: values}
+}
+
+// DeniedError reason of denied request, Status is http status: 402 for no license or expired, 403 for others
+type DeniedError struct {
+	Code   string
+	Reason string
+	Status int
+	Err    error
+}
+
+func (e *DeniedError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("license denied: %s", e.Reason)
+	}
+
+	return fmt.Sprintf("license denied: Auth(%s) %s", e.Code, e.Reason)
+}
+
+func (e *DeniedError) Unwrap() error {
+	return e.Err
+}
+
+// Gate Entitlements is the cached view of the shared verified license, e.g. Load of license.EntitlementsHolder,
+// nil is no license. The view is queried per request, not built
+type Gate struct {
+	Entitlements func() *license.Entitlements
+	OnDenied     func(http.ResponseWriter, *http.Request, *DeniedError) // optional, default is plain text with Status
+}
+
+func New(src func() *license.Entitlements) *Gate {
+	return &Gate{Entitlements: src}
+}
+
+// Check all requirements, return *DeniedError of the first unsatisfied one
+func (g *Gate) Check(reqs ...*Requirement) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+
+	e := g.Entitlements()
+	if e == nil {
+		return &DeniedError{Reason: ReasonNoLicense, Status: http.StatusPaymentRequired}
+	}
+
+	for _, r := range reqs {
+		a, err := e.Check(r.Code)
+		if err != nil {
+			if errors.Is(err, license.ErrAuthV1Expired) {
+				return &DeniedError{Code: r.Code, Reason: ReasonExpired, Status: http.StatusPaymentRequired, Err: err}
+			}
+
+			return &DeniedError{Code: r.Code, Reason: ReasonMissing, Status: http.StatusForbidden, Err: err}
+		}
+
+		if !allowed(a, r.Values) {
+			return &DeniedError{Code: r.Code, Reason: ReasonNotAllowed, Status: http.StatusForbidden}
+		}
+	}
+
+	return nil
+}
+
+func allowed(a *license.AuthV1, values []string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	if a.Type == license.AuthV1TypeList {
+		items, _ := a.List()
+		for _, v := range values {
+			if !slices.Contains(items, v) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return slices.Contains(values, a.Content)
+}
+
+// Require middleware of one route
+func (g *Gate) Require(reqs ...*Requirement) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := g.Check(reqs...); err != nil {
+				g.denied(w, r, err.(*DeniedError))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Middleware routes are path prefixes matched on segment boundaries(e.g. /api matches /api/users, not /apix),
+// the longest matched one is used, unmatched path is allowed
+func (g *Gate) Middleware(routes map[string][]*Requirement) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := g.Check(match(routes, r.URL.Path)...); err != nil {
+				g.denied(w, r, err.(*DeniedError))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (g *Gate) denied(w http.ResponseWriter, r *http.Request, e *DeniedError) {
+	if g.OnDenied != nil {
+		g.OnDenied(w, r, e)
+
+		return
+	}
+
+	http.Error(w, e.Error(), e.Status)
+}
+
+// match requirements of the longest prefix, key must be the prefix itself or under it
+func match(rules map[string][]*Requirement, key string) []*Requirement {
+	var reqs []*Requirement
+
+	n := -1
+	for k, v := range rules {
+		ok := key == k || strings.HasPrefix(key, strings.TrimSuffix(k, "/")+"/")
+		if ok && len(k) > n {
+			reqs, n = v, len(k)
+		}
+	}
+
+	return reqs
+}
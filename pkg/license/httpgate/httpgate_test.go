@@ -0,0 +1,119 @@
+package httpgate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"superlicense/pkg/license"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type HttpGateTest struct {
+	Path   string
+	Status int
+}
+
+func TestMiddleware(t *testing.T) {
+	now := time.Now()
+	l := &license.LicenseV1{Auths: []*license.AuthV1{
+		{Code: license.AuthV1CodeExpiredAt, ExpiredAt: now.Add(time.Hour).Unix()},
+		{Code: license.AuthV1CodeModel, Content: "X100"},
+		{Code: "features", Content: "export,report", Type: license.AuthV1TypeList},
+		{Code: "sso", Content: "t", ExpiredAt: now.Add(-time.Hour).Unix()},
+	}}
+
+	h := &license.EntitlementsHolder{}
+	g := New(h.Load)
+
+	m := g.Middleware(map[string][]*Requirement{
+		"/api/":        {Require(license.AuthV1CodeModel)},
+		"/api/export":  {Require("features", "export")},
+		"/api/audit":   {Require("features", "export", "audit")},
+		"/api/x200":    {Require(license.AuthV1CodeModel, "X200", "X300")},
+		"/api/sso":     {Require("sso")},
+		"/api/cluster": {Require("cluster")},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	cases := []*HttpGateTest{
+		{Path: "/", Status: http.StatusOK},
+		{Path: "/api/users", Status: http.StatusOK},
+		{Path: "/api/export", Status: http.StatusOK},
+		{Path: "/api/audit", Status: http.StatusForbidden},
+		{Path: "/api/x200", Status: http.StatusForbidden},
+		{Path: "/api/sso", Status: http.StatusPaymentRequired},
+		{Path: "/api/cluster", Status: http.StatusForbidden},
+	}
+
+	// no license
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+	assert.Contains(t, w.Body.String(), ReasonNoLicense)
+
+	h.Store(l.Entitlements(nil))
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.Path, nil))
+		assert.Equal(t, c.Status, w.Code, c.Path)
+	}
+
+	// prefix is matched on segment boundaries
+	m = g.Middleware(map[string][]*Requirement{
+		"/api": {Require("cluster")},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	cases = []*HttpGateTest{
+		{Path: "/api", Status: http.StatusForbidden},
+		{Path: "/api/users", Status: http.StatusForbidden},
+		{Path: "/apix", Status: http.StatusOK},
+		{Path: "/api-docs", Status: http.StatusOK},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, c.Path, nil))
+		assert.Equal(t, c.Status, w.Code, c.Path)
+	}
+
+	// expired license
+	h.Store(l.Entitlements(&license.AuthV1Env{Now: now.Add(2 * time.Hour)}))
+	w = httptest.NewRecorder()
+	g.Require(Require(license.AuthV1CodeModel))(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusPaymentRequired, w.Code)
+	assert.Contains(t, w.Body.String(), ReasonExpired)
+}
+
+func TestInterceptor(t *testing.T) {
+	l := &license.LicenseV1{Auths: []*license.AuthV1{{Code: license.AuthV1CodeModel, Content: "X100"}}}
+	e := l.Entitlements(nil)
+	g := New(func() *license.Entitlements { return e })
+
+	methods := map[string][]*Requirement{
+		"/demo.Service/":       {Require(license.AuthV1CodeModel)},
+		"/demo.Service/Export": {Require("features", "export")},
+	}
+
+	unary := g.UnaryServerInterceptor(methods)
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+
+	resp, err := unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/demo.Service/List"}, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/demo.Service/Export"}, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "Auth(features) missing")
+
+	stream := g.StreamServerInterceptor(methods)
+	err = stream(nil, nil, &grpc.StreamServerInfo{FullMethod: "/demo.Service/Export"}, func(srv any, ss grpc.ServerStream) error { return nil })
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	err = stream(nil, nil, &grpc.StreamServerInfo{FullMethod: "/other.Service/Watch"}, func(srv any, ss grpc.ServerStream) error { return nil })
+	assert.Nil(t, err)
+}
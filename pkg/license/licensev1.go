@@ -2,10 +2,9 @@ package license
 
 import (
 	"bytes"
-	"crypto/ed25519"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
@@ -14,6 +13,7 @@ import (
 	"os"
 
 	"superlicense/pkg/lib/aes"
+	"superlicense/pkg/lib/atomicfile"
 
 	"github.com/meilihao/gorsa"
 	"github.com/pkg/errors"
@@ -24,14 +24,15 @@ const (
 	LicenseV1Version        uint32 = 1
 	LicenseV1FlagRaw        byte   = 1 << 0
 	LicenseV1FlagCiphertext byte   = 1 << 1
+	LicenseV1FlagSignAlg    byte   = 1 << 2 // has sign_alg, without it is SignAlgV1Ed25519
 )
 
 var (
 	LicenseV1Magic = []byte("superlicense") // 12
 )
 
-// sign: ed25515
-// hash: sha256
+// sign: ed25515 | ecdsa p-256/p-384 | rsa-pss | ed25519+ML-DSA-65, see SignAlgV1xxx
+// hash: sha256 | sha384
 // encrypt: [ras]
 /*
 license schema:
@@ -39,7 +40,8 @@ license schema:
 - version(uint32): 1
 - sign_len:uint16
 - Sign_data
-- flag: byte : raw|ciphertext
+- flag: byte : raw|ciphertext|sign_alg
+- sign_alg: byte, only with sign_alg flag
 - raw_len(uint64)
 - raw_data: base on raw_len
 - key_len(uint16)
@@ -57,13 +59,14 @@ type LicenseV1 struct {
 
 	// data
 	Flag       byte
+	SignAlg    byte
 	Raw        []byte
 	CipherKey  []byte
 	Ciphertext []byte
 	Auths      []*AuthV1 // from Raw/Ciphertext
 }
 
-func ParseLicenseV1File(p string, pub ed25519.PublicKey, pubR *rsa.PublicKey) (*LicenseV1, error) {
+func ParseLicenseV1File(p string, pub crypto.PublicKey, pubR *rsa.PublicKey) (*LicenseV1, error) {
 	data, err := os.ReadFile(p)
 	if err != nil {
 		return nil, errors.Wrap(err, "load license")
@@ -77,50 +80,35 @@ func ParseLicenseV1File(p string, pub ed25519.PublicKey, pubR *rsa.PublicKey) (*
 	return ParseLicenseV1(raw, pub, pubR)
 }
 
-func ParseLicenseV1(raw []byte, pub ed25519.PublicKey, pubR *rsa.PublicKey) (*LicenseV1, error) {
-	if len(raw) < len(LicenseV1Magic)+4 { // 18 = Magic + Version
-		return nil, errors.New("invalid license header")
-	}
-
-	l := &LicenseV1{}
-
-	// parse magic, version
-	l.Magic = raw[:len(LicenseV1Magic)]
-	l.Version = binary.BigEndian.Uint32(raw[len(LicenseV1Magic) : len(LicenseV1Magic)+4])
-	if !bytes.Equal(l.Magic, LicenseV1Magic) {
-		return nil, errors.New("invalid license magic")
-	}
-	if l.Version != LicenseV1Version {
-		return nil, errors.New("invalid license version")
+// ParseLicenseV1 pub is the public key of sign key, ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey or *key.HybridPublicKey
+func ParseLicenseV1(raw []byte, pub crypto.PublicKey, pubR *rsa.PublicKey) (*LicenseV1, error) {
+	l, raw, err := parseLicenseV1Header(raw)
+	if err != nil {
+		return nil, err
 	}
 
-	// parse sign
-	raw = raw[len(LicenseV1Magic)+4:]
-	if len(raw) < 2 {
-		return nil, errors.New("invalid license sign len")
+	if len(raw) < 1 {
+		return nil, errors.New("invalid license flag")
 	}
 
-	sl := binary.BigEndian.Uint16(raw[:2])
-	if len(raw) < 2+int(sl) {
-		return nil, errors.New("invalid license sign data")
-	}
-	l.Sign = raw[2 : 2+int(sl)]
-	raw = raw[2+int(sl):]
+	l.Flag = raw[0]
+	l.SignAlg = SignAlgV1Ed25519
 
-	h := sha256.New()
-	h.Write(raw)
+	data := raw[1:]
+	if l.Flag&LicenseV1FlagSignAlg > 0 {
+		if len(data) < 1 {
+			return nil, errors.New("invalid license sign alg")
+		}
 
-	if !ed25519.Verify(pub, h.Sum(nil), l.Sign) {
-		return nil, errors.New("invalid license sign")
+		l.SignAlg = data[0]
+		data = data[1:]
 	}
 
-	if len(raw) < 1 {
-		return nil, errors.New("invalid license flag")
+	// flag and sign_alg are in the signed data, so they can't be tampered
+	if err := verifyV1(pub, l.SignAlg, raw, l.Sign); err != nil {
+		return nil, errors.Wrap(err, "invalid license sign")
 	}
 
-	l.Flag = raw[0]
-
-	data := raw[1:]
 	//os.WriteFile(fmt.Sprintf("%s.raw", "cdata"), data, 0666)
 	if l.Flag&LicenseV1FlagRaw > 0 {
 		if len(data) < 8 {
@@ -201,25 +189,78 @@ func ParseLicenseV1(raw []byte, pub ed25519.PublicKey, pubR *rsa.PublicKey) (*Li
 	return l, nil
 }
 
-func BuildLicenseV1File(licFpath string, auths []*AuthV1, priv ed25519.PrivateKey, privR *rsa.PrivateKey, flag byte) error {
+// parseLicenseV1Header parse magic, version and sign, return the signed data
+func parseLicenseV1Header(raw []byte) (*LicenseV1, []byte, error) {
+	if len(raw) < len(LicenseV1Magic)+4 { // 18 = Magic + Version
+		return nil, nil, errors.New("invalid license header")
+	}
+
+	l := &LicenseV1{}
+
+	// parse magic, version
+	l.Magic = raw[:len(LicenseV1Magic)]
+	l.Version = binary.BigEndian.Uint32(raw[len(LicenseV1Magic) : len(LicenseV1Magic)+4])
+	if !bytes.Equal(l.Magic, LicenseV1Magic) {
+		return nil, nil, errors.New("invalid license magic")
+	}
+	if l.Version != LicenseV1Version {
+		return nil, nil, errors.New("invalid license version")
+	}
+
+	// parse sign
+	raw = raw[len(LicenseV1Magic)+4:]
+	if len(raw) < 2 {
+		return nil, nil, errors.New("invalid license sign len")
+	}
+
+	sl := binary.BigEndian.Uint16(raw[:2])
+	if len(raw) < 2+int(sl) {
+		return nil, nil, errors.New("invalid license sign data")
+	}
+	l.Sign = raw[2 : 2+int(sl)]
+	raw = raw[2+int(sl):]
+
+	return l, raw, nil
+}
+
+func BuildLicenseV1File(licFpath string, auths []*AuthV1, priv crypto.Signer, privR crypto.Signer, flag byte) error {
 	data, err := BuildLicenseV1(auths, priv, privR, flag)
 	if err != nil {
 		return err
 	}
 
 	raw := base64.URLEncoding.EncodeToString(data)
-	if err = os.WriteFile(licFpath, []byte(raw), 0666); err != nil {
+	if err = atomicfile.WriteFile(licFpath, []byte(raw), 0644); err != nil {
 		return errors.Wrap(err, "save license")
 	}
 
 	return nil
 }
 
-func BuildLicenseV1(auths []*AuthV1, priv ed25519.PrivateKey, privR *rsa.PrivateKey, flag byte) ([]byte, error) {
+// BuildLicenseV1 priv and privR can be in-memory keys(ed25519.PrivateKey, *rsa.PrivateKey) or external keys(e.g. HSM)
+// - priv: key for sign, ed25519, ecdsa(p-256, p-384), rsa(rsa-pss) or *key.HybridPrivateKey, see SignAlgV1Of
+// - privR: rsa key for encrypt the aes key, only for LicenseV1FlagCiphertext
+func BuildLicenseV1(auths []*AuthV1, priv crypto.Signer, privR crypto.Signer, flag byte) ([]byte, error) {
 	if flag&LicenseV1FlagRaw == 0 && flag&LicenseV1FlagCiphertext == 0 {
 		return nil, errors.New("invalid flag")
 	}
 
+	if priv == nil {
+		return nil, errors.New("missing sign key")
+	}
+	if flag&LicenseV1FlagSignAlg > 0 {
+		return nil, errors.New("invalid flag")
+	}
+
+	signAlg, err := SignAlgV1Of(priv.Public())
+	if err != nil {
+		return nil, errors.Wrap(err, "unsupported sign key")
+	}
+	// keep the same as old license for ed25519
+	if signAlg != SignAlgV1Ed25519 {
+		flag |= LicenseV1FlagSignAlg
+	}
+
 	jdata, err := json.Marshal(auths)
 	if err != nil {
 		return nil, errors.Wrap(err, "marshal auths")
@@ -232,6 +273,9 @@ func BuildLicenseV1(auths []*AuthV1, priv ed25519.PrivateKey, privR *rsa.Private
 		if privR == nil {
 			return nil, errors.New("missing key")
 		}
+		if _, ok := privR.Public().(*rsa.PublicKey); !ok {
+			return nil, errors.New("unsupported encrypt key")
+		}
 
 		key := make([]byte, 32)
 		if _, err := io.ReadFull(rand.Reader, key); err != nil {
@@ -245,9 +289,9 @@ func BuildLicenseV1(auths []*AuthV1, priv ed25519.PrivateKey, privR *rsa.Private
 
 		//os.WriteFile(fmt.Sprintf("%s.raw", "ciphertext"), CiphertextData, 0666)
 
-		gorsa.RSA.SetPrivateKeyV2(privR)
-
-		keyData, err = gorsa.RSA.PriKeyENCTYPT(key)
+		// same as gorsa.RSA.PriKeyENCTYPT: PKCS #1 v1.5 type 1 padding without DigestInfo,
+		// so privR only needs to sign, which is supported by HSM(CKM_RSA_PKCS)
+		keyData, err = privR.Sign(rand.Reader, key, crypto.Hash(0))
 		if err != nil {
 			return nil, errors.Wrap(err, "encrypt key")
 		}
@@ -260,6 +304,9 @@ func BuildLicenseV1(auths []*AuthV1, priv ed25519.PrivateKey, privR *rsa.Private
 
 	cdata := bytes.NewBuffer(nil) // license data
 	cdata.WriteByte(flag)
+	if flag&LicenseV1FlagSignAlg > 0 {
+		cdata.WriteByte(signAlg)
+	}
 
 	if flag&LicenseV1FlagRaw > 0 {
 		l := make([]byte, 8)
@@ -291,10 +338,10 @@ func BuildLicenseV1(auths []*AuthV1, priv ed25519.PrivateKey, privR *rsa.Private
 	data.Write(version)
 
 	// write sign
-	h := sha256.New()
-	h.Write(cdata.Bytes())
-
-	sign := ed25519.Sign(priv, h.Sum(nil))
+	sign, err := signV1(priv, signAlg, cdata.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "sign")
+	}
 	if len(sign) > math.MaxUint16 {
 		panic("sign over MaxUint16")
 	}
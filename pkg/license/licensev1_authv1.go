@@ -1,46 +1,249 @@
 package license
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/pkg/errors"
 )
 
 type CreateLicenseV1Req struct {
-	Name  string
-	Auths []*AuthV1
+	Name    string
+	Edition string // optional, expanded to auths by ValidLicenseV1
+	Lang    string // optional, lang of Name/Remark injected to license by ValidLicenseV1
+	Auths   []*AuthV1
+	// optional, code -> human-friendly ExpiredAt(e.g. +365d, end-of-quarter), resolved by ValidLicenseV1,
+	// overrides ExpiredAt of the auth, the auth is added when it's absent
+	Expires map[string]string
 }
 
 type LicenserV1 interface {
 	Name() string
 	Checks() []*AuthV1Check
+	// Valid auths only, edition and rules(LicenserV1Rules) are checked by ValidLicenseV1, use it to create license
 	Valid(r *CreateLicenseV1Req) ([]*AuthV1, error)
 }
 
 var (
 	licenseV1Store = sync.Map{}
+	licenseV1Mu    sync.Mutex // for check and store
 )
 
 func RegisterLicenseV1(l LicenserV1) {
+	if err := TryRegisterLicenseV1(l); err != nil {
+		panic(err)
+	}
+}
+
+// TryRegisterLicenseV1 same as RegisterLicenseV1, but return error instead of panic, for register at runtime
+func TryRegisterLicenseV1(l LicenserV1) error {
+	licenseV1Mu.Lock()
+	defer licenseV1Mu.Unlock()
+
 	_, isExist := licenseV1Store.Load(l.Name())
 	if isExist {
-		panic(errors.Errorf("double register licensev1: %s", l.Name()))
+		return errors.Errorf("double register licensev1: %s", l.Name())
 	}
 
 	cs := l.Checks()
 	if len(cs) == 0 {
-		panic(errors.Errorf("missing Checks: %s", l.Name()))
+		return errors.Errorf("missing Checks: %s", l.Name())
 	}
 
 	m := make(map[string]bool, len(cs)) // for check double check
 
 	for _, v := range cs {
 		if m[v.Code] {
-			panic(errors.Errorf("double Check: %s, %s", l.Name(), v.Code))
+			return errors.Errorf("double Check: %s, %s", l.Name(), v.Code)
 		}
 
 		m[v.Code] = true
 	}
 
+	if le, ok := l.(LicenserV1Editions); ok {
+		if err := validEditionV1s(cs, le.Editions()); err != nil {
+			return errors.Wrapf(err, "invalid editions: %s", l.Name())
+		}
+	}
+
 	licenseV1Store.Store(l.Name(), l)
+
+	return nil
+}
+
+func GetLicenseV1(name string) (LicenserV1, bool) {
+	l, isExist := licenseV1Store.Load(name)
+	if !isExist {
+		return nil, false
+	}
+
+	return l.(LicenserV1), true
+}
+
+// ListLicenseV1 sorted by name
+func ListLicenseV1() []LicenserV1 {
+	ls := make([]LicenserV1, 0)
+	licenseV1Store.Range(func(_, v any) bool {
+		ls = append(ls, v.(LicenserV1))
+
+		return true
+	})
+
+	sort.Slice(ls, func(i, j int) bool {
+		return ls[i].Name() < ls[j].Name()
+	})
+
+	return ls
+}
+
+// ValidLicenseV1 Valid by the registered product:
+// 1. resolve Expires to auths
+// 2. expand edition to auths
+// 3. Valid
+// 4. inject edition auth, if product hasn't its own edition check
+// 5. localize by Lang
+// 6. check rules
+func ValidLicenseV1(r *CreateLicenseV1Req) ([]*AuthV1, error) {
+	l, isExist := GetLicenseV1(r.Name)
+	if !isExist {
+		return nil, errors.Errorf("unknown licensev1: %s", r.Name)
+	}
+
+	nr := &CreateLicenseV1Req{
+		Name:    r.Name,
+		Edition: r.Edition,
+		Auths:   r.Auths,
+	}
+
+	var err error
+	var errs AuthV1Errors
+	if len(r.Expires) != 0 {
+		var es AuthV1Errors
+		nr.Auths, es = resolveAuthV1Expires(l.Checks(), r.Auths, r.Expires)
+		errs = append(errs, es...)
+	}
+	if r.Edition != "" {
+		if nr.Auths, err = ExpandEditionV1(l, r.Edition, nr.Auths); err != nil {
+			es, ok := AsAuthV1Errors(err)
+			if !ok {
+				return nil, err
+			}
+
+			errs = append(errs, es...) // go on for all problems
+		}
+	}
+
+	hasEditionCheck := false
+	for _, c := range l.Checks() {
+		if c.Code == AuthV1CodeEdition {
+			hasEditionCheck = true
+		}
+	}
+	if r.Edition != "" && hasEditionCheck {
+		nr.Auths = append(nr.Auths, &AuthV1{Code: AuthV1CodeEdition, Content: r.Edition})
+	}
+
+	auths, err := l.Valid(nr)
+	if err != nil {
+		es, ok := AsAuthV1Errors(err)
+		if !ok {
+			return nil, err
+		}
+
+		errs = append(errs, es...)
+	}
+	if len(errs) != 0 {
+		// rules on the auths that validated, so all problems are reported at once
+		if lr, ok := l.(LicenserV1Rules); ok {
+			errs = append(errs, ruleErrsOf(lr.Rules(), auths, errs)...)
+		}
+
+		return nil, errs
+	}
+
+	if r.Edition != "" && !hasEditionCheck {
+		auths = append(auths, &AuthV1{
+			Code:    AuthV1CodeEdition,
+			Name:    authV1EditionCheck.Name,
+			Content: r.Edition,
+			Type:    AuthV1TypeString,
+		})
+	}
+
+	if r.Lang != "" {
+		auths = LocalizeAuthV1s(l.Checks(), auths, r.Lang)
+	}
+
+	if lr, ok := l.(LicenserV1Rules); ok {
+		if err = CheckAuthV1Rules(lr.Rules(), auths); err != nil {
+			return nil, err
+		}
+	}
+
+	return auths, nil
+}
+
+// ruleErrsOf rule errors of partial auths, except the ones pointing to the auth already in errs
+func ruleErrsOf(rules []*AuthV1Rule, auths []*AuthV1, errs AuthV1Errors) AuthV1Errors {
+	es, _ := AsAuthV1Errors(CheckAuthV1Rules(rules, auths))
+
+	failed := make(map[string]bool, len(errs))
+	for _, e := range errs {
+		failed[e.Code] = true
+	}
+
+	var res AuthV1Errors
+	for _, e := range es {
+		if e.Code == "" || !failed[e.Code] {
+			res = append(res, e)
+		}
+	}
+
+	return res
+}
+
+// resolveAuthV1Expires copy of auths with ExpiredAt resolved from expires, absent auths are appended in order of code
+func resolveAuthV1Expires(checks []*AuthV1Check, auths []*AuthV1, expires map[string]string) ([]*AuthV1, AuthV1Errors) {
+	cm := make(map[string]*AuthV1Check, len(checks))
+	for _, c := range checks {
+		cm[c.Code] = c
+	}
+
+	codes := make([]string, 0, len(expires))
+	for code := range expires {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var errs AuthV1Errors
+	ts := make(map[string]int64, len(expires))
+	for _, code := range codes {
+		t, err := ParseAuthV1Date(expires[code])
+		if err != nil {
+			errs = append(errs, newAuthV1Error(cm[code], code, AuthV1ReasonInvalid, err))
+
+			continue
+		}
+
+		ts[code] = t
+	}
+
+	nauths := make([]*AuthV1, 0, len(auths)+len(ts))
+	for _, a := range auths {
+		if t, ok := ts[a.Code]; ok {
+			na := *a // not modify auths of caller
+			na.ExpiredAt = t
+			a = &na
+			delete(ts, a.Code)
+		}
+
+		nauths = append(nauths, a)
+	}
+	for _, code := range codes {
+		if t, ok := ts[code]; ok {
+			nauths = append(nauths, &AuthV1{Code: code, ExpiredAt: t})
+		}
+	}
+
+	return nauths, errs
 }
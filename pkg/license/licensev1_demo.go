@@ -16,6 +16,17 @@ func (l *LicenseV1Demo) Checks() []*AuthV1Check {
 	return l.checks
 }
 
+// Rules try license is at most 30 days
+func (l *LicenseV1Demo) Rules() []*AuthV1Rule {
+	return []*AuthV1Rule{
+		(&AuthV1RuleDef{
+			Name: "try_expiry",
+			When: []*AuthV1Cond{{Code: AuthV1CodeIsTry, Op: AuthV1OpEq, Value: "t"}},
+			Then: []*AuthV1Cond{{Code: AuthV1CodeExpiredAt, Op: AuthV1OpWithinDays, Value: "30"}},
+		}).Rule(),
+	}
+}
+
 func (l *LicenseV1Demo) Valid(r *CreateLicenseV1Req) ([]*AuthV1, error) {
 	return GenerateAuthV1s(l.checks, r.Auths)
 }
@@ -26,6 +37,9 @@ func ReisterLicenseV1Demo() {
 			WithTry(),
 			WithExpiredAt(),
 			WithModel(),
+			WithGraceDays(30),
+			WithWarnDays(90),
+			WithTrialDays(90),
 		},
 	}
 
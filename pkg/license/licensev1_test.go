@@ -2,11 +2,16 @@ package license
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"testing"
 
+	"superlicense/pkg/key"
+
 	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/stretchr/testify/assert"
 )
@@ -15,6 +20,11 @@ type LicenseV1Test struct {
 	Flag byte
 }
 
+type LicenseV1SignTest struct {
+	Priv    crypto.Signer
+	SignAlg byte
+}
+
 func TestLicenseV1(t *testing.T) {
 	privR, err := rsa.GenerateKey(rand.Reader, 2048)
 	assert.Nil(t, err)
@@ -65,3 +75,70 @@ func TestLicenseV1(t *testing.T) {
 		assert.Equal(t, auths, l.Auths)
 	}
 }
+
+func TestLicenseV1SignAlg(t *testing.T) {
+	_, privE, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	privP256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	privP384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err)
+
+	privR, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	privH, err := key.GenerateHybridKey()
+	assert.Nil(t, err)
+
+	auths := []*AuthV1{
+		{
+			Code:    "id",
+			Name:    "ID",
+			Content: "test",
+		},
+	}
+
+	cases := []LicenseV1SignTest{
+		{
+			Priv:    privE,
+			SignAlg: SignAlgV1Ed25519,
+		},
+		{
+			Priv:    privP256,
+			SignAlg: SignAlgV1ECDSAP256,
+		},
+		{
+			Priv:    privP384,
+			SignAlg: SignAlgV1ECDSAP384,
+		},
+		{
+			Priv:    privR,
+			SignAlg: SignAlgV1RSAPSSSHA256,
+		},
+		{
+			Priv:    privH,
+			SignAlg: SignAlgV1Ed25519MLDSA,
+		},
+	}
+
+	for _, c := range cases {
+		data, err := BuildLicenseV1(auths, c.Priv, nil, LicenseV1FlagRaw)
+		assert.Nil(t, err)
+
+		l, err := ParseLicenseV1(data, c.Priv.Public(), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, c.SignAlg, l.SignAlg)
+		assert.Equal(t, auths, l.Auths)
+
+		// ed25519 keeps the old format
+		assert.Equal(t, c.SignAlg != SignAlgV1Ed25519, l.Flag&LicenseV1FlagSignAlg > 0)
+
+		// other key
+		_, err = ParseLicenseV1(data, privP256.Public(), nil)
+		if c.SignAlg != SignAlgV1ECDSAP256 {
+			assert.NotNil(t, err)
+		}
+	}
+}
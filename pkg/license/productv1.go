@@ -0,0 +1,420 @@
+package license
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"superlicense/pkg/lib/timex"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ProductV1Def 声明式的产品定义, 从yaml/json加载, 无需写代码即可新增产品
+//
+// 文件可以是单个产品, 也可以是 `products: [...]` 列表, json是yaml的子集, 同样支持
+type ProductV1Def struct {
+	Name     string           `json:"name" yaml:"name"`
+	Auths    []*AuthV1Def     `json:"auths" yaml:"auths"`
+	Rules    []*AuthV1RuleDef `json:"rules,omitempty" yaml:"rules,omitempty"` // cross-field rules
+	Editions []*EditionV1     `json:"editions,omitempty" yaml:"editions,omitempty"`
+}
+
+type ProductV1File struct {
+	Products []*ProductV1Def `json:"products" yaml:"products"`
+}
+
+type AuthV1Def struct {
+	Code            string                 `json:"code" yaml:"code"`
+	Name            string                 `json:"name,omitempty" yaml:"name,omitempty"`
+	Remark          string                 `json:"remark,omitempty" yaml:"remark,omitempty"`
+	Required        bool                   `json:"required,omitempty" yaml:"required,omitempty"`
+	RequiredContent bool                   `json:"required_content,omitempty" yaml:"required_content,omitempty"`
+	RequiredExpired bool                   `json:"required_expired,omitempty" yaml:"required_expired,omitempty"`
+	Example         string                 `json:"example,omitempty" yaml:"example,omitempty"`
+	Tip             string                 `json:"tip,omitempty" yaml:"tip,omitempty"`
+	Type            string                 `json:"type,omitempty" yaml:"type,omitempty"`       // AuthV1TypeXXX, default is inferred from validator
+	Default         *string                `json:"default,omitempty" yaml:"default,omitempty"` // only for required_content
+	Hidden          bool                   `json:"hidden,omitempty" yaml:"hidden,omitempty"`
+	I18n            map[string]*AuthV1Text `json:"i18n,omitempty" yaml:"i18n,omitempty"` // lang -> localized name/remark/tip
+	Validator       *AuthV1Validator       `json:"validator,omitempty" yaml:"validator,omitempty"`
+}
+
+const (
+	AuthV1ValidatorRegex = "regex"
+	AuthV1ValidatorEnum  = "enum"
+	AuthV1ValidatorInt   = "int"
+	AuthV1ValidatorDate  = "date"
+	AuthV1ValidatorBool  = "bool"
+	AuthV1ValidatorList  = "list"
+
+	AuthV1DateLayout = "2006-01-02"
+	AuthV1DateNow    = "now"
+)
+
+// AuthV1Validator 声明式的校验:
+// - regex: content匹配Pattern
+// - enum: content在Values中
+// - int: content为整数, 且在[Min, Max]中
+// - date: content(为空时用ExpiredAt)为时间, 且在(After, Before)中, After/Before可以是相对时间(now, +30d), 格式见ParseAuthV1Date
+// - bool: content为t或f
+// - list: content为逗号分隔, Values不为空时每项都需在Values中, Min/Max限制项数
+type AuthV1Validator struct {
+	Type    string   `json:"type" yaml:"type"`
+	Pattern string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Values  []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Min     *int64   `json:"min,omitempty" yaml:"min,omitempty"`
+	Max     *int64   `json:"max,omitempty" yaml:"max,omitempty"`
+	After   string   `json:"after,omitempty" yaml:"after,omitempty"`
+	Before  string   `json:"before,omitempty" yaml:"before,omitempty"`
+
+	re *regexp.Regexp
+}
+
+func (v *AuthV1Validator) Valid() error {
+	switch v.Type {
+	case AuthV1ValidatorRegex:
+		re, err := regexp.Compile(v.Pattern)
+		if err != nil {
+			return errors.Wrap(err, "invalid pattern")
+		}
+		v.re = re
+	case AuthV1ValidatorEnum:
+		if len(v.Values) == 0 {
+			return errors.New("missing values")
+		}
+	case AuthV1ValidatorInt, AuthV1ValidatorList:
+		if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+			return errors.New("min > max")
+		}
+	case AuthV1ValidatorDate:
+		if _, err := parseAuthV1DateBound(v.After); err != nil {
+			return errors.Wrap(err, "invalid after")
+		}
+		if _, err := parseAuthV1DateBound(v.Before); err != nil {
+			return errors.Wrap(err, "invalid before")
+		}
+	case AuthV1ValidatorBool:
+	default:
+		return errors.Errorf("unsupported validator: %s", v.Type)
+	}
+
+	return nil
+}
+
+// Check for AuthV1Check.Check, need Valid() first. It's read-only, safe for concurrent use
+func (v *AuthV1Validator) Check(content string, expiredAt int64) error {
+	switch v.Type {
+	case AuthV1ValidatorRegex:
+		if v.re == nil {
+			return errors.New("pattern isn't compiled, need Valid() first")
+		}
+		if !v.re.MatchString(content) {
+			return errors.Errorf("not match %s", v.Pattern)
+		}
+	case AuthV1ValidatorEnum:
+		if !containsString(v.Values, content) {
+			return errors.Errorf("need one of %s", strings.Join(v.Values, "|"))
+		}
+	case AuthV1ValidatorInt:
+		n, err := strconv.ParseInt(content, 10, 64)
+		if err != nil {
+			return errors.New("need integer")
+		}
+
+		return v.checkRange(n)
+	case AuthV1ValidatorDate:
+		return v.checkDate(content, expiredAt)
+	case AuthV1ValidatorBool:
+		if content != "t" && content != "f" {
+			return errors.New("need t or f")
+		}
+	case AuthV1ValidatorList:
+		items := splitAuthV1List(content)
+		for _, item := range items {
+			if len(v.Values) != 0 && !containsString(v.Values, item) {
+				return errors.Errorf("invalid item %s, need in %s", item, strings.Join(v.Values, "|"))
+			}
+		}
+
+		return errors.Wrap(v.checkRange(int64(len(items))), "invalid count")
+	default:
+		return errors.Errorf("unsupported validator: %s", v.Type)
+	}
+
+	return nil
+}
+
+func (v *AuthV1Validator) checkRange(n int64) error {
+	if v.Min != nil && n < *v.Min {
+		return errors.Errorf("less than %d", *v.Min)
+	}
+	if v.Max != nil && n > *v.Max {
+		return errors.Errorf("greater than %d", *v.Max)
+	}
+
+	return nil
+}
+
+func (v *AuthV1Validator) checkDate(content string, expiredAt int64) error {
+	ts := expiredAt
+	if content != "" {
+		var err error
+		if ts, err = ParseAuthV1Date(content); err != nil {
+			return err
+		}
+	}
+
+	if after, _ := parseAuthV1DateBound(v.After); after != 0 && ts <= after {
+		return errors.Errorf("need after %s", v.After)
+	}
+	if before, _ := parseAuthV1DateBound(v.Before); before != 0 && ts >= before {
+		return errors.Errorf("need before %s", v.Before)
+	}
+
+	return nil
+}
+
+// ParseAuthV1Date timestamp, date with optional timezone, RFC 3339, relative(+365d, +6mo) or keyword(end-of-quarter), see timex.Parse
+func ParseAuthV1Date(s string) (int64, error) {
+	t, err := timex.Parse(s, time.Now(), time.Local)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Unix(), nil
+}
+
+// parseAuthV1DateBound empty is 0, means no bound, relative bound(now, +30d) is relative to the time of check
+func parseAuthV1DateBound(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	return ParseAuthV1Date(s)
+}
+
+func splitAuthV1List(content string) []string {
+	items := make([]string, 0)
+	for _, s := range strings.Split(content, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			items = append(items, s)
+		}
+	}
+
+	return items
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+var authV1TypeOfValidator = map[string]string{
+	AuthV1ValidatorInt:  AuthV1TypeInt,
+	AuthV1ValidatorBool: AuthV1TypeBool,
+	AuthV1ValidatorList: AuthV1TypeList,
+	AuthV1ValidatorDate: AuthV1TypeTime,
+}
+
+func (d *AuthV1Def) Valid() error {
+	if d.Code == "" {
+		return errors.New("missing code")
+	}
+	if !isAuthV1Type(d.Type) {
+		return errors.Errorf("unsupported type: %s, %s", d.Code, d.Type)
+	}
+	if d.Default != nil && !d.RequiredContent {
+		return errors.Errorf("default need required_content: %s", d.Code)
+	}
+
+	if d.Validator == nil {
+		if d.RequiredContent || d.RequiredExpired {
+			return errors.Errorf("missing validator: %s", d.Code)
+		}
+
+		return nil
+	}
+
+	if err := d.Validator.Valid(); err != nil {
+		return errors.Wrapf(err, "invalid validator: %s", d.Code)
+	}
+
+	return nil
+}
+
+func (d *AuthV1Def) Check() *AuthV1Check {
+	c := &AuthV1Check{
+		Requred:        d.Required,
+		Code:           d.Code,
+		Name:           d.Name,
+		Remark:         d.Remark,
+		RequredExpired: d.RequiredExpired,
+		RequredContent: d.RequiredContent,
+		Example:        d.Example,
+		Tip:            d.Tip,
+		Validator:      d.Validator,
+		Type:           d.Type,
+		Default:        d.Default,
+		Hidden:         d.Hidden,
+		I18n:           d.I18n,
+	}
+
+	if c.Type == "" && d.Validator != nil {
+		c.Type = authV1TypeOfValidator[d.Validator.Type]
+	}
+
+	if d.Validator != nil {
+		c.Check = d.Validator.Check
+		if c.Tip == "" && d.Validator.Type == AuthV1ValidatorRegex {
+			c.Tip = d.Validator.Pattern
+		}
+	}
+
+	return c
+}
+
+func (d *ProductV1Def) Valid() error {
+	if d.Name == "" {
+		return errors.New("missing name")
+	}
+	if len(d.Auths) == 0 {
+		return errors.Errorf("missing auths: %s", d.Name)
+	}
+
+	for _, a := range d.Auths {
+		if err := a.Valid(); err != nil {
+			return errors.Wrapf(err, "invalid product: %s", d.Name)
+		}
+	}
+
+	for _, r := range d.Rules {
+		if err := r.Valid(); err != nil {
+			return errors.Wrapf(err, "invalid product: %s", d.Name)
+		}
+	}
+
+	return nil
+}
+
+// licenseV1Product LicenserV1 of ProductV1Def
+type licenseV1Product struct {
+	name     string
+	checks   []*AuthV1Check
+	rules    []*AuthV1Rule
+	editions []*EditionV1
+}
+
+func (l *licenseV1Product) Name() string {
+	return l.name
+}
+
+func (l *licenseV1Product) Checks() []*AuthV1Check {
+	return l.checks
+}
+
+func (l *licenseV1Product) Editions() []*EditionV1 {
+	return l.editions
+}
+
+func (l *licenseV1Product) Rules() []*AuthV1Rule {
+	return l.rules
+}
+
+func (l *licenseV1Product) Valid(r *CreateLicenseV1Req) ([]*AuthV1, error) {
+	return GenerateAuthV1s(l.checks, r.Auths)
+}
+
+func NewProductV1(d *ProductV1Def) (LicenserV1, error) {
+	if err := d.Valid(); err != nil {
+		return nil, err
+	}
+
+	l := &licenseV1Product{
+		name:     d.Name,
+		checks:   make([]*AuthV1Check, 0, len(d.Auths)),
+		editions: d.Editions,
+	}
+	for _, a := range d.Auths {
+		l.checks = append(l.checks, a.Check())
+	}
+	for _, v := range d.Rules {
+		l.rules = append(l.rules, v.Rule())
+	}
+
+	// also checked by TryRegisterLicenseV1, but before register for RegisterProductV1File
+	if err := validEditionV1s(l.checks, l.editions); err != nil {
+		return nil, errors.Wrapf(err, "invalid product: %s", d.Name)
+	}
+
+	return l, nil
+}
+
+// ParseProductV1s yaml or json, single product or products list
+func ParseProductV1s(data []byte) ([]*ProductV1Def, error) {
+	f := &ProductV1File{}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, errors.Wrap(err, "unmarshal products")
+	}
+	if len(f.Products) != 0 {
+		return f.Products, nil
+	}
+
+	d := &ProductV1Def{}
+	if err := yaml.Unmarshal(data, d); err != nil {
+		return nil, errors.Wrap(err, "unmarshal product")
+	}
+	if d.Name == "" {
+		return nil, errors.New("no product found")
+	}
+
+	return []*ProductV1Def{d}, nil
+}
+
+func LoadProductV1File(fpath string) ([]*ProductV1Def, error) {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return nil, errors.Wrap(err, "read products file")
+	}
+
+	return ParseProductV1s(data)
+}
+
+// RegisterProductV1File load products and register, all products are checked before register
+func RegisterProductV1File(fpath string) error {
+	ds, err := LoadProductV1File(fpath)
+	if err != nil {
+		return err
+	}
+
+	ls := make([]LicenserV1, 0, len(ds))
+	names := make(map[string]bool, len(ds)) // for double product in file
+	for _, d := range ds {
+		l, err := NewProductV1(d)
+		if err != nil {
+			return err
+		}
+		if _, isExist := GetLicenseV1(l.Name()); isExist || names[l.Name()] {
+			return errors.Errorf("double register licensev1: %s", l.Name())
+		}
+		names[l.Name()] = true
+
+		ls = append(ls, l)
+	}
+
+	for _, l := range ls {
+		if err = TryRegisterLicenseV1(l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
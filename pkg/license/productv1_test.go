@@ -0,0 +1,304 @@
+package license
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const productV1TestYaml = `
+products:
+  - name: product_v1_test
+    auths:
+      - code: expired_at
+        name: 过期时间
+        required: true
+        required_expired: true
+        validator: {type: date, after: now}
+      - code: model
+        required_content: true
+        validator: {type: regex, pattern: "^X[0-9]{3}$"}
+      - code: edition
+        required: true
+        required_content: true
+        validator: {type: enum, values: [basic, pro]}
+      - code: max_users
+        required_content: true
+        validator: {type: int, min: 1, max: 100}
+      - code: is_try
+        required_content: true
+        validator: {type: bool}
+      - code: features
+        required_content: true
+        validator: {type: list, values: [a, b, c], max: 2}
+`
+
+const productV1TestJson = `{"name": "product_v1_test_json", "auths": [{"code": "id", "required": true}]}`
+
+type ProductV1Test struct {
+	Name  string
+	Auths []*AuthV1
+	IsErr bool
+}
+
+func TestProductV1(t *testing.T) {
+	ds, err := ParseProductV1s([]byte(productV1TestYaml))
+	assert.Nil(t, err)
+	assert.Len(t, ds, 1)
+
+	l, err := NewProductV1(ds[0])
+	assert.Nil(t, err)
+
+	expiredAt := time.Now().Add(time.Hour).Unix()
+
+	cases := []*ProductV1Test{
+		{
+			Name: "ok",
+			Auths: []*AuthV1{
+				{Code: "expired_at", ExpiredAt: expiredAt},
+				{Code: "model", Content: "X100"},
+				{Code: "edition", Content: "pro"},
+				{Code: "max_users", Content: "100"},
+				{Code: "is_try", Content: "f"},
+				{Code: "features", Content: "a, c"},
+			},
+		},
+		{
+			Name:  "missing required",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: expiredAt}},
+			IsErr: true,
+		},
+		{
+			Name:  "expired",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: 1}, {Code: "edition", Content: "pro"}},
+			IsErr: true,
+		},
+		{
+			Name:  "regex",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: expiredAt}, {Code: "edition", Content: "pro"}, {Code: "model", Content: "Y100"}},
+			IsErr: true,
+		},
+		{
+			Name:  "enum",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: expiredAt}, {Code: "edition", Content: "max"}},
+			IsErr: true,
+		},
+		{
+			Name:  "int range",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: expiredAt}, {Code: "edition", Content: "pro"}, {Code: "max_users", Content: "101"}},
+			IsErr: true,
+		},
+		{
+			Name:  "bool",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: expiredAt}, {Code: "edition", Content: "pro"}, {Code: "is_try", Content: "yes"}},
+			IsErr: true,
+		},
+		{
+			Name:  "list item",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: expiredAt}, {Code: "edition", Content: "pro"}, {Code: "features", Content: "a,d"}},
+			IsErr: true,
+		},
+		{
+			Name:  "list count",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: expiredAt}, {Code: "edition", Content: "pro"}, {Code: "features", Content: "a,b,c"}},
+			IsErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		_, err := l.Valid(&CreateLicenseV1Req{Name: l.Name(), Auths: c.Auths})
+		assert.Equal(t, c.IsErr, err != nil, c.Name)
+	}
+}
+
+func TestParseProductV1sJson(t *testing.T) {
+	ds, err := ParseProductV1s([]byte(productV1TestJson))
+	assert.Nil(t, err)
+	assert.Len(t, ds, 1)
+	assert.Equal(t, "product_v1_test_json", ds[0].Name)
+
+	_, err = NewProductV1(&ProductV1Def{Name: "x", Auths: []*AuthV1Def{{Code: "a", RequiredContent: true}}})
+	assert.NotNil(t, err)
+
+	_, err = NewProductV1(&ProductV1Def{Name: "x", Auths: []*AuthV1Def{{Code: "a", RequiredContent: true, Validator: &AuthV1Validator{Type: "regex", Pattern: "("}}}})
+	assert.NotNil(t, err)
+}
+
+func TestAuthV1ValidatorRegex(t *testing.T) {
+	v := &AuthV1Validator{Type: AuthV1ValidatorRegex, Pattern: `^C[0-9]{4}$`}
+	assert.NotNil(t, v.Check("C0001", 0)) // not compiled
+
+	assert.Nil(t, v.Valid())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, v.Check("C0001", 0))
+			assert.NotNil(t, v.Check("X0001", 0))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRegisterProductV1(t *testing.T) {
+	l, err := NewProductV1(&ProductV1Def{Name: "product_v1_register", Auths: []*AuthV1Def{{Code: "id"}}})
+	assert.Nil(t, err)
+
+	assert.Nil(t, TryRegisterLicenseV1(l))
+	assert.NotNil(t, TryRegisterLicenseV1(l))
+
+	g, isExist := GetLicenseV1("product_v1_register")
+	assert.True(t, isExist)
+	assert.Equal(t, l, g)
+	assert.Contains(t, ListLicenseV1(), l)
+}
+
+func TestRegisterProductV1File(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "products.yaml")
+
+	// double in file, nothing is registered
+	assert.Nil(t, os.WriteFile(fpath, []byte(`
+products:
+  - name: product_v1_file_a
+    auths: [{code: id}]
+  - name: product_v1_file_b
+    auths: [{code: id}]
+  - name: product_v1_file_a
+    auths: [{code: id}]
+`), 0600))
+	assert.NotNil(t, RegisterProductV1File(fpath))
+	_, isExist := GetLicenseV1("product_v1_file_a")
+	assert.False(t, isExist)
+	_, isExist = GetLicenseV1("product_v1_file_b")
+	assert.False(t, isExist)
+
+	// bad edition of later product, nothing is registered
+	assert.Nil(t, os.WriteFile(fpath, []byte(`
+products:
+  - name: product_v1_file_a
+    auths: [{code: id}]
+  - name: product_v1_file_b
+    auths: [{code: id}]
+    editions: [{name: pro, fixed: {max_users: "10"}}]
+`), 0600))
+	assert.ErrorContains(t, RegisterProductV1File(fpath), "unsupported Auth of edition(pro)")
+	_, isExist = GetLicenseV1("product_v1_file_a")
+	assert.False(t, isExist)
+
+	assert.Nil(t, os.WriteFile(fpath, []byte(`
+products:
+  - name: product_v1_file_a
+    auths: [{code: id}]
+  - name: product_v1_file_b
+    auths: [{code: id}]
+`), 0600))
+	assert.Nil(t, RegisterProductV1File(fpath))
+	_, isExist = GetLicenseV1("product_v1_file_b")
+	assert.True(t, isExist)
+
+	// double with registered
+	assert.NotNil(t, RegisterProductV1File(fpath))
+}
+
+const productV1RuleTestYaml = `
+name: product_v1_rule_test
+auths:
+  - code: expired_at
+    required: true
+    required_expired: true
+    validator: {type: date, after: now}
+  - code: is_try
+    required_content: true
+    validator: {type: bool}
+  - code: model
+    required_content: true
+    validator: {type: regex, pattern: "^X[0-9]{3}$"}
+  - code: max_users
+    required_content: true
+    validator: {type: int, min: 1}
+rules:
+  - name: try_expiry
+    when: [{code: is_try, op: eq, value: t}]
+    then: [{code: expired_at, op: within_days, value: "30"}]
+  - name: x900_users
+    when: [{code: model, op: eq, value: X900}]
+    then: [{code: max_users, op: ge, value: "100"}]
+    message: X900 requires max_users >= 100
+`
+
+func TestProductV1Rules(t *testing.T) {
+	ds, err := ParseProductV1s([]byte(productV1RuleTestYaml))
+	assert.Nil(t, err)
+
+	l, err := NewProductV1(ds[0])
+	assert.Nil(t, err)
+	assert.Nil(t, TryRegisterLicenseV1(l))
+
+	day := int64(24 * 3600)
+	now := time.Now().Unix()
+
+	cases := []*ProductV1Test{
+		{
+			Name:  "try in 30 days",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: now + 10*day}, {Code: "is_try", Content: "t"}},
+		},
+		{
+			Name:  "not try",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: now + 100*day}, {Code: "is_try", Content: "f"}},
+		},
+		{
+			Name:  "x900 with users",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: now + day}, {Code: "model", Content: "X900"}, {Code: "max_users", Content: "100"}},
+		},
+		{
+			Name:  "try too long",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: now + 100*day}, {Code: "is_try", Content: "t"}},
+			IsErr: true,
+		},
+		{
+			Name:  "x900 without users",
+			Auths: []*AuthV1{{Code: "expired_at", ExpiredAt: now + day}, {Code: "model", Content: "X900"}},
+			IsErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		_, err := ValidLicenseV1(&CreateLicenseV1Req{Name: l.Name(), Auths: c.Auths})
+		assert.Equal(t, c.IsErr, err != nil, c.Name)
+	}
+
+	// all violations are reported
+	_, err = ValidLicenseV1(&CreateLicenseV1Req{Name: l.Name(), Auths: []*AuthV1{
+		{Code: "expired_at", ExpiredAt: now + 100*day},
+		{Code: "is_try", Content: "t"},
+		{Code: "model", Content: "X900"},
+		{Code: "max_users", Content: "10"},
+	}})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "try_expiry")
+	assert.Contains(t, err.Error(), "X900 requires max_users >= 100")
+
+	// rules are checked on the auths that validated, with errors of auths
+	_, err = ValidLicenseV1(&CreateLicenseV1Req{Name: l.Name(), Auths: []*AuthV1{
+		{Code: "expired_at", ExpiredAt: now + 100*day},
+		{Code: "is_try", Content: "t"},
+		{Code: "model", Content: "X900"},
+		{Code: "max_users", Content: "x"},
+	}})
+	es, ok := AsAuthV1Errors(err)
+	assert.True(t, ok)
+	if assert.Len(t, es, 2) {
+		assert.Equal(t, "max_users", es[0].Code)
+		assert.Equal(t, AuthV1ReasonInvalid, es[0].Reason)
+		assert.Equal(t, "try_expiry", es[1].Rule)
+	}
+
+	_, err = NewProductV1(&ProductV1Def{Name: "x", Auths: []*AuthV1Def{{Code: "a"}}, Rules: []*AuthV1RuleDef{{Name: "r", Then: []*AuthV1Cond{{Code: "a", Op: "like"}}}}})
+	assert.NotNil(t, err)
+}
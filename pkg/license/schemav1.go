@@ -0,0 +1,336 @@
+package license
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+)
+
+// LicenseV1Schema JSON Schema of auths of product, for form-driven UIs:
+// - property is auth code, edition is a property when product has editions
+// - auth only requires expired: value is the expiration, date or timestamp
+// - auth requires content and expired: value is {"content": xxx, "expired_at": xxx}
+// - otherwise: value is the content, typed by AuthV1Check.Type
+//
+// The document can be submitted by ParseCreateLicenseV1Req
+func LicenseV1Schema(l LicenserV1, lang string) map[string]any {
+	props := make(map[string]any)
+	required := make([]string, 0)
+
+	for _, c := range l.Checks() {
+		props[c.Code] = authV1CheckSchema(c, lang)
+
+		if c.Requred && c.Default == nil {
+			required = append(required, c.Code)
+		}
+	}
+
+	if le, ok := l.(LicenserV1Editions); ok && len(le.Editions()) != 0 {
+		if _, isExist := props[AuthV1CodeEdition]; !isExist {
+			names := make([]string, 0)
+			for _, e := range le.Editions() {
+				names = append(names, e.Name)
+			}
+
+			props[AuthV1CodeEdition] = map[string]any{
+				"title": authV1EditionCheck.Text(lang).Name,
+				"type":  "string",
+				"enum":  names,
+			}
+		}
+	}
+
+	return map[string]any{
+		"$schema":              JSONSchemaDraft,
+		"title":                l.Name(),
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+func MarshalLicenseV1Schema(name, lang string) ([]byte, error) {
+	l, isExist := GetLicenseV1(name)
+	if !isExist {
+		return nil, errors.Errorf("unknown licensev1: %s", name)
+	}
+
+	return json.MarshalIndent(LicenseV1Schema(l, lang), "", "  ")
+}
+
+func authV1CheckSchema(c *AuthV1Check, lang string) map[string]any {
+	var s map[string]any
+
+	switch {
+	case c.RequredContent && c.RequredExpired:
+		s = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"content":    authV1ContentSchema(c),
+				"expired_at": authV1TimeSchema(),
+			},
+			"required":             []string{"content", "expired_at"},
+			"additionalProperties": false,
+		}
+	case c.RequredExpired:
+		s = authV1TimeSchema()
+	case c.RequredContent:
+		s = authV1ContentSchema(c)
+
+		if c.Default != nil {
+			if v, err := authV1ContentToValue(authV1TypeOf(c), *c.Default); err == nil {
+				s["default"] = v
+			}
+		}
+		if c.Example != "" {
+			if v, err := authV1ContentToValue(authV1TypeOf(c), c.Example); err == nil {
+				s["examples"] = []any{v}
+			}
+		}
+	default: // only code
+		s = map[string]any{"type": "boolean", "const": true}
+	}
+
+	text := c.Text(lang)
+	s["title"] = text.Name
+
+	desc := make([]string, 0, 2)
+	for _, v := range []string{text.Remark, text.Tip} {
+		if v != "" {
+			desc = append(desc, v)
+		}
+	}
+	if len(desc) != 0 {
+		s["description"] = strings.Join(desc, "; ")
+	}
+
+	return s
+}
+
+func authV1TimeSchema() map[string]any {
+	return map[string]any{
+		"anyOf": []any{
+			map[string]any{"type": "string", "description": "date with optional timezone, RFC 3339, +365d, +6mo or end-of-quarter"},
+			map[string]any{"type": "integer", "description": "unix timestamp"},
+		},
+	}
+}
+
+// authV1TypeOf declared type, or inferred from validator
+func authV1TypeOf(c *AuthV1Check) string {
+	if c.Type == "" && c.Validator != nil {
+		return authV1TypeOfValidator[c.Validator.Type]
+	}
+
+	return c.Type
+}
+
+func authV1ContentSchema(c *AuthV1Check) map[string]any {
+	var s map[string]any
+
+	switch authV1TypeOf(c) {
+	case AuthV1TypeBool:
+		s = map[string]any{"type": "boolean"}
+	case AuthV1TypeInt:
+		s = map[string]any{"type": "integer"}
+	case AuthV1TypeList:
+		s = map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "uniqueItems": true}
+	case AuthV1TypeTime:
+		s = authV1TimeSchema()
+	default:
+		s = map[string]any{"type": "string"}
+	}
+
+	v := c.Validator
+	if v == nil {
+		return s
+	}
+
+	switch v.Type {
+	case AuthV1ValidatorRegex:
+		s["pattern"] = v.Pattern
+	case AuthV1ValidatorEnum:
+		s["enum"] = v.Values
+	case AuthV1ValidatorInt:
+		if v.Min != nil {
+			s["minimum"] = *v.Min
+		}
+		if v.Max != nil {
+			s["maximum"] = *v.Max
+		}
+	case AuthV1ValidatorList:
+		if len(v.Values) != 0 {
+			s["items"] = map[string]any{"type": "string", "enum": v.Values}
+		}
+		if v.Min != nil {
+			s["minItems"] = *v.Min
+		}
+		if v.Max != nil {
+			s["maxItems"] = *v.Max
+		}
+	}
+
+	return s
+}
+
+// authV1ContentToValue content to typed json value
+func authV1ContentToValue(typ, content string) (any, error) {
+	content, err := normalizeAuthV1Content(typ, content)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case AuthV1TypeBool:
+		return content == "t", nil
+	case AuthV1TypeInt, AuthV1TypeTime:
+		return json.Number(content), nil
+	case AuthV1TypeList:
+		return splitAuthV1List(content), nil
+	default:
+		return content, nil
+	}
+}
+
+// authV1ValueToContent typed json value to content
+func authV1ValueToContent(v any) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case bool:
+		if t {
+			return "t", nil
+		}
+
+		return "f", nil
+	case json.Number:
+		return t.String(), nil
+	case []any:
+		items := make([]string, 0, len(t))
+		for _, item := range t {
+			s, ok := item.(string)
+			if !ok {
+				return "", errors.New("need string item")
+			}
+
+			items = append(items, s)
+		}
+
+		return strings.Join(items, ","), nil
+	default:
+		return "", errors.Errorf("unsupported value: %v", v)
+	}
+}
+
+// ParseCreateLicenseV1Req parse json document of LicenseV1Schema
+func ParseCreateLicenseV1Req(name string, data []byte) (*CreateLicenseV1Req, error) {
+	l, isExist := GetLicenseV1(name)
+	if !isExist {
+		return nil, errors.Errorf("unknown licensev1: %s", name)
+	}
+
+	values := make(map[string]any)
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&values); err != nil {
+		return nil, errors.Wrap(err, "unmarshal values")
+	}
+
+	cm := make(map[string]*AuthV1Check)
+	for _, c := range l.Checks() {
+		cm[c.Code] = c
+	}
+
+	r := &CreateLicenseV1Req{Name: name}
+	if _, ok := l.(LicenserV1Editions); ok && cm[AuthV1CodeEdition] == nil {
+		if v, ok := values[AuthV1CodeEdition]; ok {
+			r.Edition = fmt.Sprint(v)
+			delete(values, AuthV1CodeEdition)
+		}
+	}
+
+	// in order of checks, then unknown codes sorted, for stable auths and errors
+	codes := make([]string, 0, len(values))
+	for _, c := range l.Checks() {
+		if _, ok := values[c.Code]; ok {
+			codes = append(codes, c.Code)
+		}
+	}
+	unknown := make([]string, 0)
+	for code := range values {
+		if cm[code] == nil {
+			unknown = append(unknown, code)
+		}
+	}
+	sort.Strings(unknown)
+	codes = append(codes, unknown...)
+
+	var errs AuthV1Errors
+	for _, code := range codes {
+		v := values[code]
+		a, err := authV1FromValue(cm[code], code, v)
+		if err != nil {
+			errs = append(errs, newAuthV1Error(cm[code], code, AuthV1ReasonInvalid, err))
+
+			continue
+		}
+
+		r.Auths = append(r.Auths, a)
+	}
+	if len(errs) != 0 {
+		return nil, errs
+	}
+
+	return r, nil
+}
+
+func authV1FromValue(c *AuthV1Check, code string, v any) (*AuthV1, error) {
+	a := &AuthV1{Code: code}
+
+	var err error
+	switch {
+	case c != nil && c.RequredContent && c.RequredExpired:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, errors.New("need {content, expired_at}")
+		}
+		if a.Content, err = authV1ValueToContent(m["content"]); err != nil {
+			return nil, err
+		}
+		if a.ExpiredAt, err = authV1ValueToTime(m["expired_at"]); err != nil {
+			return nil, err
+		}
+	case c != nil && c.RequredExpired:
+		if a.ExpiredAt, err = authV1ValueToTime(v); err != nil {
+			return nil, err
+		}
+	default:
+		if b, ok := v.(bool); ok && b && c != nil && !c.RequredContent { // only code
+			return a, nil
+		}
+		if a.Content, err = authV1ValueToContent(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+func authV1ValueToTime(v any) (int64, error) {
+	s, err := authV1ValueToContent(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return ParseAuthV1Date(s)
+}
@@ -0,0 +1,72 @@
+package license
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaV1Demo struct {
+	LicenseV1Demo
+}
+
+func (l *schemaV1Demo) Name() string {
+	return "schema_v1_test"
+}
+
+func (l *schemaV1Demo) Editions() []*EditionV1 {
+	return []*EditionV1{{Name: "basic"}, {Name: "pro", Inherit: "basic", Open: map[string]string{AuthV1CodeMaxUsers: "100"}}}
+}
+
+func TestLicenseV1Schema(t *testing.T) {
+	l := &schemaV1Demo{LicenseV1Demo{checks: []*AuthV1Check{WithTry(), WithExpiredAt(), WithModel(), WithMaxUsers(1, 1000), WithFeatures("a", "b")}}}
+	assert.Nil(t, TryRegisterLicenseV1(l))
+
+	data, err := MarshalLicenseV1Schema(l.Name(), LangEn)
+	assert.Nil(t, err)
+
+	s := map[string]any{}
+	assert.Nil(t, json.Unmarshal(data, &s))
+	assert.Equal(t, JSONSchemaDraft, s["$schema"])
+	assert.Equal(t, []any{AuthV1CodeExpiredAt}, s["required"])
+
+	props := s["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "boolean", "default": false, "title": "Trial"}, props[AuthV1CodeIsTry])
+	assert.Equal(t, `^X[0-9]{3}$`, props[AuthV1CodeModel].(map[string]any)["pattern"])
+	assert.Equal(t, float64(1000), props[AuthV1CodeMaxUsers].(map[string]any)["maximum"])
+	assert.Equal(t, []any{float64(1000)}, props[AuthV1CodeMaxUsers].(map[string]any)["examples"])
+	assert.Equal(t, "array", props[AuthV1CodeFeatures].(map[string]any)["type"])
+	assert.Equal(t, []any{"basic", "pro"}, props[AuthV1CodeEdition].(map[string]any)["enum"])
+	assert.NotNil(t, props[AuthV1CodeExpiredAt].(map[string]any)["anyOf"])
+
+	// submit the form
+	form := `{"edition": "pro", "is_try": true, "expired_at": "` + time.Now().AddDate(0, 0, 7).Format(AuthV1DateLayout) + `", "model": "X100", "features": ["a", "b"]}`
+	r, err := ParseCreateLicenseV1Req(l.Name(), []byte(form))
+	assert.Nil(t, err)
+	assert.Equal(t, "pro", r.Edition)
+
+	auths, err := ValidLicenseV1(r)
+	assert.Nil(t, err)
+
+	lic := &LicenseV1{Auths: auths}
+	n, _ := lic.Int(AuthV1CodeMaxUsers)
+	assert.Equal(t, int64(100), n)
+	b, _ := lic.Bool(AuthV1CodeIsTry)
+	assert.True(t, b)
+	fs, _ := lic.List(AuthV1CodeFeatures)
+	assert.Equal(t, []string{"a", "b"}, fs)
+
+	_, err = ParseCreateLicenseV1Req(l.Name(), []byte(`{"expired_at": "someday"}`))
+	assert.NotNil(t, err)
+
+	// auths in order of checks, then unknown codes sorted
+	r, err = ParseCreateLicenseV1Req(l.Name(), []byte(`{"z": "1", "features": ["a"], "y": "2", "model": "X100", "is_try": true}`))
+	assert.Nil(t, err)
+	codes := make([]string, 0, len(r.Auths))
+	for _, a := range r.Auths {
+		codes = append(codes, a.Code)
+	}
+	assert.Equal(t, []string{AuthV1CodeIsTry, AuthV1CodeModel, AuthV1CodeFeatures, "y", "z"}, codes)
+}
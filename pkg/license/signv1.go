@@ -0,0 +1,116 @@
+package license
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+
+	"superlicense/pkg/key"
+
+	"github.com/pkg/errors"
+)
+
+// sign algorithm of LicenseV1, the algorithm is decided by the type of sign key
+const (
+	SignAlgV1Ed25519      byte = 1 // ed25519(sha256(data)), default when without LicenseV1FlagSignAlg
+	SignAlgV1ECDSAP256    byte = 2 // ecdsa p-256 with sha256, asn.1 signature
+	SignAlgV1ECDSAP384    byte = 3 // ecdsa p-384 with sha384, asn.1 signature
+	SignAlgV1RSAPSSSHA256 byte = 4 // rsa-pss with sha256, salt length equals hash
+	SignAlgV1Ed25519MLDSA byte = 5 // hybrid post-quantum: ed25519 || ML-DSA-65, both sign data directly and both must be valid
+)
+
+var (
+	ErrUnsupportSignAlg = errors.New("unsupport sign algorithm")
+)
+
+// SignAlgV1Of get sign algorithm by public key
+func SignAlgV1Of(pub crypto.PublicKey) (byte, error) {
+	switch v := pub.(type) {
+	case ed25519.PublicKey:
+		return SignAlgV1Ed25519, nil
+	case *ecdsa.PublicKey:
+		switch v.Curve {
+		case elliptic.P256():
+			return SignAlgV1ECDSAP256, nil
+		case elliptic.P384():
+			return SignAlgV1ECDSAP384, nil
+		}
+	case *rsa.PublicKey:
+		return SignAlgV1RSAPSSSHA256, nil
+	case *key.HybridPublicKey:
+		return SignAlgV1Ed25519MLDSA, nil
+	}
+
+	return 0, ErrUnsupportSignAlg
+}
+
+func signV1(priv crypto.Signer, alg byte, data []byte) ([]byte, error) {
+	switch alg {
+	case SignAlgV1Ed25519:
+		h := sha256.Sum256(data)
+
+		return priv.Sign(rand.Reader, h[:], crypto.Hash(0))
+	case SignAlgV1ECDSAP256:
+		h := sha256.Sum256(data)
+
+		return priv.Sign(rand.Reader, h[:], crypto.SHA256)
+	case SignAlgV1ECDSAP384:
+		h := sha512.Sum384(data)
+
+		return priv.Sign(rand.Reader, h[:], crypto.SHA384)
+	case SignAlgV1RSAPSSSHA256:
+		h := sha256.Sum256(data)
+
+		return priv.Sign(rand.Reader, h[:], &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       crypto.SHA256,
+		})
+	case SignAlgV1Ed25519MLDSA:
+		return priv.Sign(rand.Reader, data, crypto.Hash(0))
+	default:
+		return nil, ErrUnsupportSignAlg
+	}
+}
+
+// verifyV1 the type of pub must match alg, avoid algorithm confusion
+func verifyV1(pub crypto.PublicKey, alg byte, data, sign []byte) error {
+	expected, err := SignAlgV1Of(pub)
+	if err != nil {
+		return err
+	}
+	if expected != alg {
+		return errors.New("sign algorithm mismatch with key")
+	}
+
+	ok := false
+
+	switch alg {
+	case SignAlgV1Ed25519:
+		h := sha256.Sum256(data)
+		ok = ed25519.Verify(pub.(ed25519.PublicKey), h[:], sign)
+	case SignAlgV1ECDSAP256:
+		h := sha256.Sum256(data)
+		ok = ecdsa.VerifyASN1(pub.(*ecdsa.PublicKey), h[:], sign)
+	case SignAlgV1ECDSAP384:
+		h := sha512.Sum384(data)
+		ok = ecdsa.VerifyASN1(pub.(*ecdsa.PublicKey), h[:], sign)
+	case SignAlgV1RSAPSSSHA256:
+		h := sha256.Sum256(data)
+		ok = rsa.VerifyPSS(pub.(*rsa.PublicKey), crypto.SHA256, h[:], sign, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		}) == nil
+	case SignAlgV1Ed25519MLDSA:
+		ok = pub.(*key.HybridPublicKey).Verify(data, sign)
+	}
+
+	if !ok {
+		return errors.New("invalid sign")
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package license
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"superlicense/pkg/lib/atomicfile"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TicketV1Version uint32 = 1
+)
+
+var (
+	TicketV1Magic = []byte("slticket") // 8
+
+	ErrTicketExpired         = errors.New("time ticket expired")
+	ErrTicketLicenseMismatch = errors.New("time ticket is not for the license")
+)
+
+// TicketV1 signed time ticket for air-gapped machine without trusted clock(e.g. no ntp).
+// The vendor issues it with the sign key of license, and IssuedAt is a trusted lower bound of now,
+// see AuthV1Env.Ticket.
+//
+// After NotAfter the whole license is expired(ErrTicketExpired) by policy, not only the ticket is ignored:
+// without trusted clock, a new ticket is the only proof that the license is still in use as agreed.
+/*
+ticket schema:
+- magic: "slticket"
+- version(uint32): 1
+- sign_len:uint16
+- Sign_data: sign of magic + version + the following, magic is domain separation from license sign
+- sign_alg: byte
+- data_len(uint16)
+- data: json of TicketV1
+
+> version and xxx_len use bigendian
+*/
+type TicketV1 struct {
+	LicenseID string `json:"license_id"` // see LicenseV1.ID
+	IssuedAt  int64  `json:"issued_at"`  // unix time of vendor
+	NotAfter  int64  `json:"not_after"`  // unix time, ticket is expired after it, need a new ticket
+}
+
+// ID of license, hex of sha256(sign)[:16], sign is unique for each license
+func (l *LicenseV1) ID() string {
+	h := sha256.Sum256(l.Sign)
+
+	return hex.EncodeToString(h[:16])
+}
+
+// LicenseV1IDOfFile ID of license file without verify and decrypt, for vendor to issue ticket
+func LicenseV1IDOfFile(p string) (string, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", errors.Wrap(err, "load license")
+	}
+
+	raw, err := base64.URLEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "", errors.Wrap(err, "decode license")
+	}
+
+	l, _, err := parseLicenseV1Header(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return l.ID(), nil
+}
+
+// NewTicketV1 ticket of license for now, valid for validity
+func NewTicketV1(licenseID string, now time.Time, validity time.Duration) *TicketV1 {
+	return &TicketV1{
+		LicenseID: licenseID,
+		IssuedAt:  now.Unix(),
+		NotAfter:  now.Add(validity).Unix(),
+	}
+}
+
+func (t *TicketV1) Valid() error {
+	if t.LicenseID == "" {
+		return errors.New("missing license id")
+	}
+	if t.IssuedAt <= 0 {
+		return errors.New("invalid issued_at")
+	}
+	if t.NotAfter < t.IssuedAt {
+		return errors.New("not_after before issued_at")
+	}
+
+	return nil
+}
+
+// Check ticket is for license l and not expired, now is from local clock and is raised to IssuedAt
+func (t *TicketV1) Check(l *LicenseV1, now time.Time) error {
+	if t.LicenseID != l.ID() {
+		return ErrTicketLicenseMismatch
+	}
+	if t.Now(now).Unix() > t.NotAfter {
+		return ErrTicketExpired
+	}
+
+	return nil
+}
+
+// Now max of now and IssuedAt
+func (t *TicketV1) Now(now time.Time) time.Time {
+	if issuedAt := time.Unix(t.IssuedAt, 0); now.Before(issuedAt) {
+		return issuedAt
+	}
+
+	return now
+}
+
+func BuildTicketV1File(p string, t *TicketV1, priv crypto.Signer) error {
+	data, err := BuildTicketV1(t, priv)
+	if err != nil {
+		return err
+	}
+
+	raw := base64.URLEncoding.EncodeToString(data)
+	if err = atomicfile.WriteFile(p, []byte(raw), 0644); err != nil {
+		return errors.Wrap(err, "save ticket")
+	}
+
+	return nil
+}
+
+// BuildTicketV1 priv is the sign key of license, see BuildLicenseV1
+func BuildTicketV1(t *TicketV1, priv crypto.Signer) ([]byte, error) {
+	if err := t.Valid(); err != nil {
+		return nil, errors.Wrap(err, "invalid ticket")
+	}
+	if priv == nil {
+		return nil, errors.New("missing sign key")
+	}
+
+	signAlg, err := SignAlgV1Of(priv.Public())
+	if err != nil {
+		return nil, errors.Wrap(err, "unsupported sign key")
+	}
+
+	jdata, err := json.Marshal(t)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal ticket")
+	}
+	if len(jdata) > math.MaxUint16 {
+		return nil, errors.New("ticket too large")
+	}
+
+	cdata := bytes.NewBuffer(nil) // ticket data
+	cdata.WriteByte(signAlg)
+
+	dl := make([]byte, 2)
+	binary.BigEndian.PutUint16(dl, uint16(len(jdata)))
+	cdata.Write(dl)
+	cdata.Write(jdata)
+
+	header := ticketV1Header()
+
+	sign, err := signV1(priv, signAlg, append(header, cdata.Bytes()...))
+	if err != nil {
+		return nil, errors.Wrap(err, "sign")
+	}
+	if len(sign) > math.MaxUint16 {
+		panic("sign over MaxUint16")
+	}
+
+	data := bytes.NewBuffer(nil)
+	data.Write(header)
+
+	sl := make([]byte, 2)
+	binary.BigEndian.PutUint16(sl, uint16(len(sign)))
+	data.Write(sl)
+	data.Write(sign)
+
+	data.Write(cdata.Bytes())
+
+	return data.Bytes(), nil
+}
+
+// ticketV1Header magic + version, also signed
+func ticketV1Header() []byte {
+	header := make([]byte, len(TicketV1Magic)+4)
+	copy(header, TicketV1Magic)
+	binary.BigEndian.PutUint32(header[len(TicketV1Magic):], TicketV1Version)
+
+	return header
+}
+
+func ParseTicketV1File(p string, pub crypto.PublicKey) (*TicketV1, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "load ticket")
+	}
+
+	raw, err := base64.URLEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "decode ticket")
+	}
+
+	return ParseTicketV1(raw, pub)
+}
+
+// ParseTicketV1 pub is the public key of sign key, same as ParseLicenseV1
+func ParseTicketV1(raw []byte, pub crypto.PublicKey) (*TicketV1, error) {
+	if len(raw) < len(TicketV1Magic)+4 {
+		return nil, errors.New("invalid ticket header")
+	}
+	if !bytes.Equal(raw[:len(TicketV1Magic)], TicketV1Magic) {
+		return nil, errors.New("invalid ticket magic")
+	}
+	if binary.BigEndian.Uint32(raw[len(TicketV1Magic):len(TicketV1Magic)+4]) != TicketV1Version {
+		return nil, errors.New("invalid ticket version")
+	}
+
+	raw = raw[len(TicketV1Magic)+4:]
+	if len(raw) < 2 {
+		return nil, errors.New("invalid ticket sign len")
+	}
+
+	sl := binary.BigEndian.Uint16(raw[:2])
+	if len(raw) < 2+int(sl) {
+		return nil, errors.New("invalid ticket sign data")
+	}
+	sign := raw[2 : 2+int(sl)]
+	raw = raw[2+int(sl):]
+
+	if len(raw) < 3 {
+		return nil, errors.New("invalid ticket data len")
+	}
+
+	// sign_alg is in the signed data, so it can't be tampered
+	if err := verifyV1(pub, raw[0], append(ticketV1Header(), raw...), sign); err != nil {
+		return nil, errors.Wrap(err, "invalid ticket sign")
+	}
+
+	dl := binary.BigEndian.Uint16(raw[1:3])
+	if len(raw) != 3+int(dl) {
+		return nil, errors.New("invalid ticket data")
+	}
+
+	t := &TicketV1{}
+	if err := json.Unmarshal(raw[3:], t); err != nil {
+		return nil, errors.Wrap(err, "parse ticket")
+	}
+	if err := t.Valid(); err != nil {
+		return nil, errors.Wrap(err, "invalid ticket")
+	}
+
+	return t, nil
+}
+
+// UseTicket check ticket t for license l, and use it as the lower bound of Now
+func (env *AuthV1Env) UseTicket(l *LicenseV1, t *TicketV1) error {
+	if err := t.Check(l, env.now()); err != nil {
+		return err
+	}
+
+	env.Ticket = t
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package license
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicketV1(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	data, err := BuildLicenseV1([]*AuthV1{{Code: "id", Content: "test"}}, priv, nil, LicenseV1FlagRaw)
+	assert.Nil(t, err)
+	l, err := ParseLicenseV1(data, pub, nil)
+	assert.Nil(t, err)
+	assert.Len(t, l.ID(), 32)
+
+	issuedAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	fpath := filepath.Join(t.TempDir(), "ticket.dat")
+	assert.Nil(t, BuildTicketV1File(fpath, NewTicketV1(l.ID(), issuedAt, 24*time.Hour), priv))
+
+	tk, err := ParseTicketV1File(fpath, pub)
+	assert.Nil(t, err)
+	assert.Equal(t, issuedAt.Unix(), tk.IssuedAt)
+
+	// rolled back clock is raised to IssuedAt
+	env := &AuthV1Env{Now: issuedAt.Add(-365 * 24 * time.Hour)}
+	assert.Nil(t, env.UseTicket(l, tk))
+	assert.Equal(t, issuedAt.Unix(), env.now().Unix())
+
+	exp := []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: issuedAt.Add(-time.Hour).Unix()}}
+	assert.NotNil(t, EvalAuthV1s([]*AuthV1Check{WithExpiredAt()}, exp, env))
+	assert.Equal(t, ExpiryExpired, ExpiryOfAuthV1s(exp, env).State)
+
+	// expired ticket
+	env = &AuthV1Env{Now: issuedAt.Add(48 * time.Hour)}
+	assert.ErrorIs(t, env.UseTicket(l, tk), ErrTicketExpired)
+	env.Ticket = tk
+	assert.ErrorIs(t, EvalAuthV1s(nil, nil, env), ErrTicketExpired)
+
+	// by policy, the whole license is expired with the ticket, even the auths aren't
+	valid := []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: issuedAt.AddDate(1, 0, 0).Unix()}, {Code: "id", Content: "test"}}
+	assert.ErrorIs(t, EvalAuthV1s([]*AuthV1Check{WithExpiredAt()}, valid, env), ErrTicketExpired)
+	s := ExpiryOfAuthV1s(valid, env)
+	assert.Equal(t, ExpiryExpired, s.State)
+	assert.ErrorIs(t, s.Err, ErrTicketExpired)
+	assert.False(t, (&LicenseV1{Auths: valid}).Entitlements(env).Has("id"))
+
+	// ticket of other license
+	assert.ErrorIs(t, (&AuthV1Env{Now: issuedAt}).UseTicket(&LicenseV1{Sign: []byte("other")}, tk), ErrTicketLicenseMismatch)
+
+	// other key
+	pub2, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, err = ParseTicketV1File(fpath, pub2)
+	assert.NotNil(t, err)
+
+	ecPriv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, err = ParseTicketV1File(fpath, &ecPriv.PublicKey)
+	assert.NotNil(t, err)
+
+	// tampered
+	raw, _ := BuildTicketV1(tk, priv)
+	raw[len(raw)-2] ^= 1
+	_, err = ParseTicketV1(raw, pub)
+	assert.NotNil(t, err)
+
+	// ecdsa
+	raw, err = BuildTicketV1(tk, ecPriv)
+	assert.Nil(t, err)
+	_, err = ParseTicketV1(raw, &ecPriv.PublicKey)
+	assert.Nil(t, err)
+
+	// sign without magic and version, e.g. sign of other format, isn't accepted
+	cdata := append([]byte{SignAlgV1Ed25519, 0, 0}, []byte(`{}`)...)
+	cdata[2] = byte(len(cdata) - 3)
+	sign, err := signV1(priv, SignAlgV1Ed25519, cdata)
+	assert.Nil(t, err)
+	raw = append(ticketV1Header(), byte(len(sign)>>8), byte(len(sign)))
+	raw = append(append(raw, sign...), cdata...)
+	_, err = ParseTicketV1(raw, pub)
+	assert.ErrorContains(t, err, "invalid ticket sign")
+
+	_, err = BuildTicketV1(&TicketV1{LicenseID: l.ID()}, priv)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,247 @@
+package license
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"time"
+
+	"superlicense/pkg/lib/atomicfile"
+	"superlicense/pkg/mark"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TrialV1Version      = 1
+	AuthV1CodeTrialDays = "trial_days"
+)
+
+var (
+	ErrTrialStateTampered = errors.New("trial state tampered")
+
+	trialV1Info = []byte("superlicense trial v1")
+)
+
+// WithTrialDays days of offline trial in [1, max], only for trial policy, see TrialV1
+func WithTrialDays(max int64) *AuthV1Check {
+	return withDaysCheck(AuthV1CodeTrialDays, "试用天数", "Trial Days", 1, max)
+}
+
+// trialV1State state file, Mac is hmac-sha256 of the other fields
+type trialV1State struct {
+	Version   int    `json:"version"`
+	StartedAt int64  `json:"started_at"`
+	LastSeen  int64  `json:"last_seen"` // highest unix time seen, for clock rollback
+	Mac       []byte `json:"mac"`
+}
+
+func (s *trialV1State) mac(key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	json.NewEncoder(h).Encode([]int64{int64(s.Version), s.StartedAt, s.LastSeen})
+
+	return h.Sum(nil)
+}
+
+// TrialV1 offline self-service trial, without vendor to build license.
+//
+// Policy is a license with is_try=t and trial_days signed by vendor, and is embedded in the binary(e.g. go:embed),
+// expired_at of policy is the last day of trial for all users. On first run, the trial starts and the state is
+// written to all Paths, bound to the machine by marks. The trial is reset only when all state files are deleted,
+// so put them in different places(e.g. config dir, data dir, hidden file in home).
+//
+// The trial state is kept after a real license is imported, so the trial can't restart, see OpenLicenseV1OrTrial.
+type TrialV1 struct {
+	Policy    *LicenseV1
+	Paths     []string
+	Tolerance time.Duration // backwards within tolerance is allowed, 0 is ClockV1DefaultTolerance
+	key       []byte
+}
+
+// NewTrialV1 policy must be verified by ParseLicenseV1, marks with error are ignored
+func NewTrialV1(policy *LicenseV1, paths []string, marks []*mark.Mark) (*TrialV1, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("missing paths of trial state")
+	}
+	if b, err := policy.Bool(AuthV1CodeIsTry); err != nil || !b {
+		return nil, errors.New("policy isn't trial")
+	}
+	if n, err := policy.Int(AuthV1CodeTrialDays); err != nil || n <= 0 {
+		return nil, errors.New("invalid trial_days of policy")
+	}
+
+	key, err := deriveV1Key(policy.Sign, marks, trialV1Info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrialV1{
+		Policy: policy,
+		Paths:  paths,
+		key:    key,
+	}, nil
+}
+
+func (t *TrialV1) tolerance() time.Duration {
+	if t.Tolerance == 0 {
+		return ClockV1DefaultTolerance
+	}
+
+	return t.Tolerance
+}
+
+// load merge state of all paths: the earliest StartedAt and the highest LastSeen, nil when without state
+func (t *TrialV1) load() (*trialV1State, error) {
+	var s *trialV1State
+
+	for _, p := range t.Paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, errors.Wrap(err, "read trial state")
+		}
+
+		v := &trialV1State{}
+		if err = json.Unmarshal(data, v); err != nil {
+			return nil, ErrTrialStateTampered
+		}
+		if v.Version != TrialV1Version || !hmac.Equal(v.Mac, v.mac(t.key)) {
+			return nil, ErrTrialStateTampered
+		}
+
+		if s == nil {
+			s = v
+			continue
+		}
+		if v.StartedAt < s.StartedAt {
+			s.StartedAt = v.StartedAt
+		}
+		if v.LastSeen > s.LastSeen {
+			s.LastSeen = v.LastSeen
+		}
+	}
+
+	return s, nil
+}
+
+// save to all paths, ok when any is saved
+func (t *TrialV1) save(s *trialV1State) error {
+	s.Version = TrialV1Version
+	s.Mac = s.mac(t.key)
+
+	data, _ := json.Marshal(s)
+
+	var err error
+	saved := false
+	for _, p := range t.Paths {
+		if e := atomicfile.WriteFile(p, data, 0600); e != nil {
+			err = e
+			continue
+		}
+
+		saved = true
+	}
+	if !saved {
+		return errors.Wrap(err, "write trial state")
+	}
+
+	return nil
+}
+
+// StartedAt zero when the trial isn't started
+func (t *TrialV1) StartedAt() (time.Time, error) {
+	s, err := t.load()
+	if err != nil || s == nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(s.StartedAt, 0), nil
+}
+
+// Start the trial on first run, or continue it. Return the trial license: auths of policy with
+// expired_at = min(StartedAt + trial_days, expired_at of policy), check it by Expiry or EvalAuthV1s.
+// Sign of the trial license is of policy, so its ID() is the same for every trial user, don't use it
+// to tell trial machines apart, e.g. use marks instead
+func (t *TrialV1) Start(now time.Time) (*LicenseV1, error) {
+	s, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if s == nil {
+		s = &trialV1State{
+			StartedAt: now.Unix(),
+			LastSeen:  now.Unix(),
+		}
+	}
+
+	last := time.Unix(s.LastSeen, 0)
+	if now.Before(last.Add(-t.tolerance())) {
+		return nil, errors.Wrapf(ErrClockRollback, "now %s, last seen %s", now.Format(time.RFC3339), last.Format(time.RFC3339))
+	}
+	if now.After(last) {
+		s.LastSeen = now.Unix()
+	}
+
+	// also restore deleted state files
+	if err = t.save(s); err != nil {
+		return nil, err
+	}
+
+	return t.license(s), nil
+}
+
+func (t *TrialV1) license(s *trialV1State) *LicenseV1 {
+	days, _ := t.Policy.Int(AuthV1CodeTrialDays)
+	expiredAt := time.Unix(s.StartedAt, 0).Add(time.Duration(days) * 24 * time.Hour).Unix()
+
+	c := WithExpiredAt()
+	exp := &AuthV1{Code: c.Code, Name: c.Name, Remark: c.Remark, Type: c.Type}
+	auths := make([]*AuthV1, 0, len(t.Policy.Auths)+1)
+	for _, a := range t.Policy.Auths {
+		na := *a
+		if a.Code == AuthV1CodeExpiredAt {
+			exp = &na
+			continue
+		}
+
+		auths = append(auths, &na)
+	}
+
+	if exp.ExpiredAt == 0 || exp.ExpiredAt > expiredAt {
+		exp.ExpiredAt = expiredAt
+	}
+	exp.Content = ""
+	auths = append(auths, exp)
+
+	l := *t.Policy
+	l.Auths = auths
+
+	return &l
+}
+
+// OpenLicenseV1OrTrial use the real license when it exists, otherwise start or continue the trial.
+// A broken license is an error, not a fallback to the trial
+func OpenLicenseV1OrTrial(p string, pub crypto.PublicKey, pubR *rsa.PublicKey, trial *TrialV1, now time.Time) (l *LicenseV1, isTrial bool, err error) {
+	if _, err = os.Stat(p); err == nil {
+		l, err = ParseLicenseV1File(p, pub, pubR)
+
+		return l, false, err
+	}
+	if !os.IsNotExist(err) {
+		return nil, false, errors.Wrap(err, "load license")
+	}
+	if trial == nil {
+		return nil, false, errors.New("missing license")
+	}
+
+	l, err = trial.Start(now)
+
+	return l, true, err
+}
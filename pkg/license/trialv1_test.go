@@ -0,0 +1,105 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"superlicense/pkg/mark"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrialV1(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	now := time.Now()
+	policyAuths, err := ValidLicenseV1(&CreateLicenseV1Req{Name: "demo", Auths: []*AuthV1{
+		{Code: AuthV1CodeIsTry, Content: "t"},
+		{Code: AuthV1CodeTrialDays, Content: "14"},
+		{Code: AuthV1CodeExpiredAt, ExpiredAt: now.Add(20 * 24 * time.Hour).Unix()},
+		{Code: AuthV1CodeModel, Content: "X100"},
+	}})
+	assert.Nil(t, err)
+
+	data, err := BuildLicenseV1(policyAuths, priv, nil, LicenseV1FlagRaw)
+	assert.Nil(t, err)
+	policy, err := ParseLicenseV1(data, pub, nil)
+	assert.Nil(t, err)
+
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a", ".trial"), filepath.Join(dir, ".trial")}
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "a"), 0700))
+	marks := []*mark.Mark{{K: mark.MarkCodeMachineid, V: "machine-1"}}
+
+	tr, err := NewTrialV1(policy, paths, marks)
+	assert.Nil(t, err)
+
+	started, err := tr.StartedAt()
+	assert.Nil(t, err)
+	assert.True(t, started.IsZero())
+
+	l, err := tr.Start(now)
+	assert.Nil(t, err)
+	exp, _ := l.Time(AuthV1CodeExpiredAt)
+	assert.Equal(t, now.Add(14*24*time.Hour).Unix(), exp.Unix())
+	model, _ := l.Text(AuthV1CodeModel)
+	assert.Equal(t, "X100", model)
+	assert.Equal(t, ExpiryValid, l.Expiry(&AuthV1Env{Now: now}).State)
+
+	// deleting one state file doesn't reset the trial
+	later := now.Add(15 * 24 * time.Hour)
+	assert.Nil(t, os.Remove(paths[0]))
+	l, err = tr.Start(later)
+	assert.Nil(t, err)
+	assert.Equal(t, ExpiryExpired, l.Expiry(&AuthV1Env{Now: later}).State)
+	_, err = os.Stat(paths[0])
+	assert.Nil(t, err)
+
+	// clock rollback
+	_, err = tr.Start(now)
+	assert.ErrorIs(t, err, ErrClockRollback)
+
+	// state of other machine
+	tr2, _ := NewTrialV1(policy, paths, []*mark.Mark{{K: mark.MarkCodeMachineid, V: "machine-2"}})
+	_, err = tr2.Start(later)
+	assert.ErrorIs(t, err, ErrTrialStateTampered)
+
+	// real license is used when exists
+	licFpath := filepath.Join(dir, "license.dat")
+	l, isTrial, err := OpenLicenseV1OrTrial(licFpath, pub, nil, tr, later)
+	assert.Nil(t, err)
+	assert.True(t, isTrial)
+
+	assert.Nil(t, BuildLicenseV1File(licFpath, []*AuthV1{{Code: AuthV1CodeModel, Content: "X200"}}, priv, nil, LicenseV1FlagRaw))
+	l, isTrial, err = OpenLicenseV1OrTrial(licFpath, pub, nil, tr, later)
+	assert.Nil(t, err)
+	assert.False(t, isTrial)
+	model, _ = l.Text(AuthV1CodeModel)
+	assert.Equal(t, "X200", model)
+
+	// policy of trial is limited by its expired_at
+	tr3, _ := NewTrialV1(policy, []string{filepath.Join(dir, ".trial3")}, marks)
+	l, err = tr3.Start(now.Add(10 * 24 * time.Hour))
+	assert.Nil(t, err)
+	exp, _ = l.Time(AuthV1CodeExpiredAt)
+	assert.Equal(t, now.Add(20*24*time.Hour).Unix(), exp.Unix())
+
+	// expired_at is injected when policy hasn't it, ID is of policy
+	policy4 := &LicenseV1{Sign: []byte("x"), Auths: []*AuthV1{{Code: AuthV1CodeIsTry, Content: "t"}, {Code: AuthV1CodeTrialDays, Content: "30"}}}
+	tr4, err := NewTrialV1(policy4, []string{filepath.Join(dir, ".trial4")}, marks)
+	assert.Nil(t, err)
+	l, err = tr4.Start(now)
+	assert.Nil(t, err)
+	if a := l.Auth(AuthV1CodeExpiredAt); assert.NotNil(t, a) {
+		assert.Equal(t, WithExpiredAt().Name, a.Name)
+		assert.Equal(t, now.Add(30*24*time.Hour).Unix(), a.ExpiredAt)
+	}
+	assert.Equal(t, policy4.ID(), l.ID())
+
+	_, err = NewTrialV1(&LicenseV1{Sign: []byte("x"), Auths: []*AuthV1{{Code: AuthV1CodeIsTry, Content: "f"}}}, paths, marks)
+	assert.NotNil(t, err)
+}
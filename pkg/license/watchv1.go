@@ -0,0 +1,296 @@
+package license
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	WatchDefaultInterval = 5 * time.Second
+	WatchDefaultVerify   = time.Hour
+)
+
+type WatchEventType int
+
+const (
+	WatchLoaded    WatchEventType = iota + 1 // first valid license that passes verification
+	WatchChanged                             // license file is replaced by a valid license that passes verification
+	WatchInvalid                             // missing, broken, or failed to verify(include a new license already expired), Err is the reason
+	WatchExpiring                            // ExpiringSoon or InGrace
+	WatchExpired                             // the loaded license is expired
+	WatchRecovered                           // the latest valid license passes verification again after WatchInvalid or WatchExpired
+)
+
+func (t WatchEventType) String() string {
+	switch t {
+	case WatchLoaded:
+		return "Loaded"
+	case WatchChanged:
+		return "Changed"
+	case WatchInvalid:
+		return "Invalid"
+	case WatchExpiring:
+		return "Expiring"
+	case WatchExpired:
+		return "Expired"
+	case WatchRecovered:
+		return "Recovered"
+	default:
+		return "Unknown"
+	}
+}
+
+type WatchEvent struct {
+	Type    WatchEventType
+	License *LicenseV1    // the latest valid license, nil when never loaded
+	Expiry  *ExpiryStatus // with License
+	Err     error         // reason of WatchInvalid, or clock rollback/expired ticket of WatchExpired
+}
+
+// WatchKeys same as ParseLicenseV1
+type WatchKeys struct {
+	Pub  crypto.PublicKey
+	PubR *rsa.PublicKey
+}
+
+type WatchOptions struct {
+	Interval time.Duration     // poll license file, 0 is WatchDefaultInterval
+	Verify   time.Duration     // re-run verification, 0 is WatchDefaultVerify
+	Product  string            // optional, evaluate by checks of registered product, see EvalLicenseV1
+	Env      func() *AuthV1Env // optional, env of each verification, nil is NewAuthV1Env
+	OnEvent  func(*WatchEvent) // optional, events are delivered by it instead of the channel
+}
+
+type watcher struct {
+	path string
+	keys *WatchKeys
+	opts WatchOptions
+	ch   chan *WatchEvent
+
+	sum     []byte // sha256 of license file
+	l       *LicenseV1
+	state   ExpiryState
+	invalid string     // last error, avoid duplicate WatchInvalid
+	broken  bool       // the file isn't of l, no WatchRecovered until it's replaced
+	pending *LicenseV1 // license of file failing verification, verified again periodically
+}
+
+// Watch license file in background until ctx is done: re-parse when the file is changed(polling, also works
+// for replacing by rename), and re-run verification periodically. Events are only delivered on change of state,
+// the channel is closed when ctx is done.
+//
+// A new license failing verification(e.g. MAC isn't present yet, or already expired) isn't loaded, it's reported
+// by WatchInvalid instead of WatchLoaded/WatchExpired, and is verified again periodically until it passes.
+func Watch(ctx context.Context, path string, keys *WatchKeys, opts *WatchOptions) (<-chan *WatchEvent, error) {
+	if keys == nil || keys.Pub == nil {
+		return nil, errors.New("missing key")
+	}
+
+	w := &watcher{
+		path: path,
+		keys: keys,
+		ch:   make(chan *WatchEvent, 8),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Interval <= 0 {
+		w.opts.Interval = WatchDefaultInterval
+	}
+	if w.opts.Verify <= 0 {
+		w.opts.Verify = WatchDefaultVerify
+	}
+
+	go w.run(ctx)
+
+	return w.ch, nil
+}
+
+func (w *watcher) run(ctx context.Context) {
+	defer close(w.ch)
+
+	poll := time.NewTicker(w.opts.Interval)
+	defer poll.Stop()
+	verify := time.NewTicker(w.opts.Verify)
+	defer verify.Stop()
+
+	w.reload(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			w.reload(ctx)
+		case <-verify.C:
+			w.verify(ctx)
+		}
+	}
+}
+
+// reload when the content of file is changed
+func (w *watcher) reload(ctx context.Context) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.sum, w.broken, w.pending = nil, true, nil
+		w.fail(ctx, errors.Wrap(err, "load license"))
+
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	if bytes.Equal(sum[:], w.sum) {
+		return
+	}
+	w.sum, w.pending = sum[:], nil
+
+	raw, err := base64.URLEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		w.broken = true
+		w.fail(ctx, errors.Wrap(err, "decode license"))
+
+		return
+	}
+
+	l, err := ParseLicenseV1(raw, w.keys.Pub, w.keys.PubR)
+	if err != nil {
+		w.broken = true
+		w.fail(ctx, err)
+
+		return
+	}
+
+	w.load(ctx, l)
+}
+
+// load license l of file when it passes verification, otherwise it's pending
+func (w *watcher) load(ctx context.Context, l *LicenseV1) {
+	s, err := w.eval(l)
+	if err != nil {
+		w.broken, w.pending = true, l
+		w.fail(ctx, err)
+
+		return
+	}
+
+	typ := WatchChanged
+	if w.l == nil {
+		typ = WatchLoaded
+	}
+
+	w.l, w.state, w.invalid, w.broken, w.pending = l, 0, "", false, nil
+	w.send(ctx, &WatchEvent{Type: typ, License: l, Expiry: s})
+	w.report(ctx, s, nil)
+}
+
+// verify re-run evaluation of the pending license, or the latest valid license
+func (w *watcher) verify(ctx context.Context) {
+	if w.pending != nil {
+		w.load(ctx, w.pending)
+
+		return
+	}
+	if w.l == nil {
+		return
+	}
+
+	s, err := w.eval(w.l)
+	w.report(ctx, s, err)
+}
+
+// eval license l, Clock of env is checked once for both evaluation and expiry
+func (w *watcher) eval(l *LicenseV1) (*ExpiryStatus, error) {
+	e := w.env()
+	env := *e
+	env.Clock = nil
+
+	if e.Clock != nil {
+		now, err := e.Clock.effective(env.now())
+		if err != nil {
+			s := l.Expiry(&env)
+			s.State, s.Err = ExpiryExpired, err
+
+			return s, err
+		}
+
+		env.Now = now
+	}
+
+	var err error
+	if w.opts.Product != "" {
+		err = EvalLicenseV1(w.opts.Product, l.Auths, &env)
+	} else {
+		err = EvalAuthV1s(nil, l.Auths, &env)
+	}
+
+	return l.Expiry(&env), err
+}
+
+// report expiry on change of state, and failure or recovery of verification
+func (w *watcher) report(ctx context.Context, s *ExpiryStatus, err error) {
+	recovered := false
+	if s.State != w.state {
+		recovered = w.state == ExpiryExpired // e.g. clock is corrected
+		w.state = s.State
+
+		switch s.State {
+		case ExpiryExpiringSoon, ExpiryInGrace:
+			w.send(ctx, &WatchEvent{Type: WatchExpiring, License: w.l, Expiry: s})
+		case ExpiryExpired:
+			w.send(ctx, &WatchEvent{Type: WatchExpired, License: w.l, Expiry: s, Err: s.Err})
+		}
+	}
+
+	// expired is delivered above
+	if err != nil && s.State != ExpiryExpired {
+		w.fail(ctx, err)
+
+		return
+	}
+
+	if err == nil && w.invalid != "" && !w.broken {
+		w.invalid, recovered = "", true
+	}
+	if recovered && s.State != ExpiryExpired {
+		w.send(ctx, &WatchEvent{Type: WatchRecovered, License: w.l, Expiry: s})
+	}
+}
+
+func (w *watcher) env() *AuthV1Env {
+	if w.opts.Env != nil {
+		return w.opts.Env()
+	}
+
+	return NewAuthV1Env()
+}
+
+func (w *watcher) fail(ctx context.Context, err error) {
+	if w.invalid == err.Error() {
+		return
+	}
+	w.invalid = err.Error()
+
+	w.send(ctx, &WatchEvent{Type: WatchInvalid, License: w.l, Err: err})
+}
+
+func (w *watcher) send(ctx context.Context, e *WatchEvent) {
+	if w.opts.OnEvent != nil {
+		w.opts.OnEvent(e)
+
+		return
+	}
+
+	select {
+	case w.ch <- e:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,244 @@
+package license
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nextWatchEvent(t *testing.T, ch <-chan *WatchEvent) *WatchEvent {
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait event timeout")
+	}
+
+	return nil
+}
+
+func TestWatch(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	fpath := filepath.Join(t.TempDir(), "license.dat")
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	var offset atomic.Int64
+	env := func() *AuthV1Env {
+		return &AuthV1Env{Now: now.Add(time.Duration(offset.Load())), Warn: 24 * time.Hour}
+	}
+
+	build := func(expiredAt time.Time) {
+		auths := []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: expiredAt.Unix()}}
+		assert.Nil(t, BuildLicenseV1File(fpath, auths, priv, nil, LicenseV1FlagRaw))
+	}
+
+	_, err := Watch(context.Background(), fpath, nil, nil)
+	assert.NotNil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := Watch(ctx, fpath, &WatchKeys{Pub: pub}, &WatchOptions{Interval: 10 * time.Millisecond, Verify: 10 * time.Millisecond, Env: env})
+	assert.Nil(t, err)
+
+	// missing
+	e := nextWatchEvent(t, ch)
+	assert.Equal(t, WatchInvalid, e.Type)
+	assert.Nil(t, e.License)
+
+	build(now.Add(10 * 24 * time.Hour))
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchLoaded, e.Type)
+	assert.Equal(t, ExpiryValid, e.Expiry.State)
+
+	// renewal
+	build(now.Add(20 * 24 * time.Hour))
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchChanged, e.Type)
+
+	// expiry mid-run
+	offset.Store(int64(20*24*time.Hour - time.Hour))
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchExpiring, e.Type)
+	assert.Equal(t, ExpiryExpiringSoon, e.Expiry.State)
+
+	offset.Store(int64(21 * 24 * time.Hour))
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchExpired, e.Type)
+
+	// broken
+	assert.Nil(t, os.WriteFile(fpath, []byte("broken"), 0644))
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchInvalid, e.Type)
+	assert.NotNil(t, e.License)
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchOnEvent(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	fpath := filepath.Join(t.TempDir(), "license.dat")
+	assert.Nil(t, BuildLicenseV1File(fpath, []*AuthV1{{Code: "id", Content: "test"}}, priv, nil, LicenseV1FlagRaw))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan *WatchEvent, 8)
+	ch, err := Watch(ctx, fpath, &WatchKeys{Pub: pub}, &WatchOptions{
+		Interval: 10 * time.Millisecond,
+		Env:      func() *AuthV1Env { return &AuthV1Env{} },
+		OnEvent:  func(e *WatchEvent) { events <- e },
+	})
+	assert.Nil(t, err)
+
+	e := nextWatchEvent(t, events)
+	assert.Equal(t, WatchLoaded, e.Type)
+	assert.Equal(t, "test", e.License.Auths[0].Content)
+
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+type watchV1Demo struct {
+	LicenseV1Demo
+}
+
+func (l *watchV1Demo) Name() string {
+	return "watch_v1_test"
+}
+
+// registerWatchV1Demo product bound to macs, shared by tests
+func registerWatchV1Demo(t *testing.T) *watchV1Demo {
+	l := &watchV1Demo{LicenseV1Demo{checks: []*AuthV1Check{WithExpiredAt(), WithMACs()}}}
+	if _, isExist := GetLicenseV1(l.Name()); !isExist {
+		assert.Nil(t, TryRegisterLicenseV1(l))
+	}
+
+	return l
+}
+
+func TestWatchRecovered(t *testing.T) {
+	l := registerWatchV1Demo(t)
+
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	fpath := filepath.Join(t.TempDir(), "license.dat")
+	auths := []*AuthV1{{Code: AuthV1CodeMACs, Content: "00:16:3e:00:00:01"}}
+	assert.Nil(t, BuildLicenseV1File(fpath, auths, priv, nil, LicenseV1FlagRaw))
+
+	var moved atomic.Bool // the licensed mac is moved to another machine
+	env := func() *AuthV1Env {
+		if moved.Load() {
+			return &AuthV1Env{MACs: []string{"00:16:3e:00:00:02"}}
+		}
+
+		return &AuthV1Env{MACs: []string{"00:16:3e:00:00:01"}}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := Watch(ctx, fpath, &WatchKeys{Pub: pub}, &WatchOptions{Interval: 10 * time.Millisecond, Verify: 10 * time.Millisecond, Product: l.Name(), Env: env})
+	assert.Nil(t, err)
+
+	e := nextWatchEvent(t, ch)
+	assert.Equal(t, WatchLoaded, e.Type)
+
+	moved.Store(true)
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchInvalid, e.Type)
+	assert.ErrorContains(t, e.Err, "no licensed mac")
+
+	moved.Store(false)
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchRecovered, e.Type)
+	assert.NotNil(t, e.License)
+
+	// broken file isn't recovered by verification of the latest valid license
+	assert.Nil(t, os.WriteFile(fpath, []byte("broken"), 0644))
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchInvalid, e.Type)
+	select {
+	case e = <-ch:
+		t.Fatalf("unexpected event: %s", e.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchPending(t *testing.T) {
+	l := registerWatchV1Demo(t)
+
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	fpath := filepath.Join(t.TempDir(), "license.dat")
+	auths := []*AuthV1{{Code: AuthV1CodeMACs, Content: "00:16:3e:00:00:01"}}
+	assert.Nil(t, BuildLicenseV1File(fpath, auths, priv, nil, LicenseV1FlagRaw))
+
+	var ready atomic.Bool // the licensed mac isn't present at startup, e.g. nic isn't up yet
+	env := func() *AuthV1Env {
+		if ready.Load() {
+			return &AuthV1Env{MACs: []string{"00:16:3e:00:00:01"}}
+		}
+
+		return &AuthV1Env{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := Watch(ctx, fpath, &WatchKeys{Pub: pub}, &WatchOptions{Interval: 10 * time.Millisecond, Verify: 10 * time.Millisecond, Product: l.Name(), Env: env})
+	assert.Nil(t, err)
+
+	e := nextWatchEvent(t, ch)
+	assert.Equal(t, WatchInvalid, e.Type)
+	assert.Nil(t, e.License)
+
+	// the same file is loaded by verification
+	ready.Store(true)
+	e = nextWatchEvent(t, ch)
+	assert.Equal(t, WatchLoaded, e.Type)
+	assert.NotNil(t, e.License)
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestWatchExpiredAtStartup(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	fpath := filepath.Join(t.TempDir(), "license.dat")
+	auths := []*AuthV1{{Code: AuthV1CodeExpiredAt, ExpiredAt: time.Now().Add(-time.Hour).Unix()}}
+	assert.Nil(t, BuildLicenseV1File(fpath, auths, priv, nil, LicenseV1FlagRaw))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := Watch(ctx, fpath, &WatchKeys{Pub: pub}, &WatchOptions{Interval: 10 * time.Millisecond, Verify: 10 * time.Millisecond})
+	assert.Nil(t, err)
+
+	// not loaded, WatchInvalid instead of WatchLoaded/WatchExpired
+	e := nextWatchEvent(t, ch)
+	assert.Equal(t, WatchInvalid, e.Type)
+	assert.Nil(t, e.License)
+	select {
+	case e = <-ch:
+		t.Fatalf("unexpected event: %s", e.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	for range ch {
+	}
+}
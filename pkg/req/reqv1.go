@@ -2,6 +2,7 @@ package req
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -13,6 +14,7 @@ import (
 	"os"
 
 	"superlicense/pkg/lib/aes"
+	"superlicense/pkg/lib/atomicfile"
 	"superlicense/pkg/mark"
 
 	"github.com/pkg/errors"
@@ -60,7 +62,7 @@ type ReqV1 struct {
 	Marks      []*mark.Mark // from Raw/Ciphertext
 }
 
-func ParseReqV1File(p string, privR *rsa.PrivateKey) (*ReqV1, error) {
+func ParseReqV1File(p string, privR crypto.Decrypter) (*ReqV1, error) {
 	data, err := os.ReadFile(p)
 	if err != nil {
 		return nil, errors.Wrap(err, "load license req")
@@ -74,7 +76,8 @@ func ParseReqV1File(p string, privR *rsa.PrivateKey) (*ReqV1, error) {
 	return ParseReqV1(raw, privR)
 }
 
-func ParseReqV1(raw []byte, privR *rsa.PrivateKey) (*ReqV1, error) {
+// ParseReqV1 privR can be *rsa.PrivateKey or external key(e.g. HSM) supported RSA-OAEP
+func ParseReqV1(raw []byte, privR crypto.Decrypter) (*ReqV1, error) {
 	if len(raw) < len(ReqV1Magic)+5 { // 18 = Magic + Version + flag
 		return nil, errors.New("invalid license req header")
 	}
@@ -159,7 +162,10 @@ func ParseReqV1(raw []byte, privR *rsa.PrivateKey) (*ReqV1, error) {
 			return nil, errors.New("invalid license req ciphertext data missing part")
 		}
 
-		key, err := rsa.DecryptOAEP(sha256.New(), nil, privR, r.CipherKey, reqV1Lable)
+		key, err := privR.Decrypt(rand.Reader, r.CipherKey, &rsa.OAEPOptions{
+			Hash:  crypto.SHA256,
+			Label: reqV1Lable,
+		})
 		if err != nil {
 			return nil, errors.Wrap(err, "get key")
 		}
@@ -181,7 +187,7 @@ func BuildReqV1File(licFpath string, marks []*mark.Mark, pubR *rsa.PublicKey, fl
 	}
 
 	raw := base64.URLEncoding.EncodeToString(data)
-	if err = os.WriteFile(licFpath, []byte(raw), 0666); err != nil {
+	if err = atomicfile.WriteFile(licFpath, []byte(raw), 0644); err != nil {
 		return errors.Wrap(err, "save license req")
 	}
 